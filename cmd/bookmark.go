@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// 书签以 @name 的形式在任何接受路径的地方使用，例如 of @work 或 of @work/src/cmd。
+// 书签名不区分大小写（配置文件中统一保存为小写）。
+
+var bmCmd = &cobra.Command{
+	Use:     "bm",
+	Aliases: []string{"bookmark"},
+	Short:   "manage named bookmarks",
+	Long: `Manage named bookmarks.
+
+A bookmark can be used anywhere a path is accepted:
+  of @work              # open the bookmarked directory
+  of @work/src/cmd      # open a path below it`,
+}
+
+var bmAddCmd = &cobra.Command{
+	Use:   "add [name] [path]",
+	Short: "add or update a bookmark (defaults to current directory)",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := normalizeBookmarkName(args[0])
+		if err := validateBookmarkName(name); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		warnLowercased(args[0], name)
+
+		target := "."
+		if len(args) > 1 {
+			target = args[1]
+		}
+		target = expandBookmark(target)
+		if !isPathValid(target) {
+			fmt.Printf("❌ Error: path does not exist: %s\n", target)
+			os.Exit(1)
+		}
+		absPath, err := filepath.Abs(target)
+		if err != nil {
+			fmt.Printf("❌ Error: cannot get absolute path: %v\n", err)
+			os.Exit(1)
+		}
+
+		if config.Bookmarks == nil {
+			config.Bookmarks = make(map[string]string)
+		}
+		config.Bookmarks[name] = absPath
+		saveBookmarks()
+
+		fmt.Printf("🔖 Added bookmark: @%s -> %s\n", name, formatPath(absPath))
+	},
+}
+
+var bmRemoveCmd = &cobra.Command{
+	Use:               "rm [name]",
+	Aliases:           []string{"remove"},
+	Short:             "remove a bookmark",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeBookmarkNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := normalizeBookmarkName(args[0])
+		if _, exists := config.Bookmarks[name]; !exists {
+			fmt.Printf("❌ Bookmark @%s not found\n", name)
+			os.Exit(1)
+		}
+
+		delete(config.Bookmarks, name)
+		saveBookmarks()
+
+		fmt.Printf("✅ Removed bookmark: @%s\n", name)
+	},
+}
+
+var bmListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "list bookmarks (missing targets are flagged)",
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		if len(config.Bookmarks) == 0 {
+			fmt.Println("🔖 No bookmarks found")
+			return
+		}
+
+		missing := 0
+		fmt.Println("🔖 Bookmarks:")
+		for _, name := range bookmarkNames() {
+			target := config.Bookmarks[name]
+			if isPathValid(target) {
+				fmt.Printf("  @%s -> %s\n", name, formatPath(target))
+			} else {
+				fmt.Printf("  ⚠️ @%s -> %s (missing)\n", name, formatPath(target))
+				missing++
+			}
+		}
+
+		if missing > 0 {
+			fmt.Printf("⚠️ %d bookmark(s) point to paths that no longer exist\n", missing)
+		}
+	},
+}
+
+var bmRenameCmd = &cobra.Command{
+	Use:               "rename [old] [new]",
+	Aliases:           []string{"mv"},
+	Short:             "rename a bookmark",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeBookmarkNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		oldName := normalizeBookmarkName(args[0])
+		newName := normalizeBookmarkName(args[1])
+
+		target, exists := config.Bookmarks[oldName]
+		if !exists {
+			fmt.Printf("❌ Bookmark @%s not found\n", oldName)
+			os.Exit(1)
+		}
+		if err := validateBookmarkName(newName); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		warnLowercased(args[1], newName)
+		if _, exists := config.Bookmarks[newName]; exists {
+			fmt.Printf("❌ Bookmark @%s already exists\n", newName)
+			os.Exit(1)
+		}
+
+		delete(config.Bookmarks, oldName)
+		config.Bookmarks[newName] = target
+		saveBookmarks()
+
+		fmt.Printf("✅ Renamed bookmark: @%s -> @%s\n", oldName, newName)
+	},
+}
+
+// normalizeBookmarkName 去掉可选的 @ 前缀并转换为小写
+func normalizeBookmarkName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+}
+
+// validateBookmarkName 检查书签名是否合法
+func validateBookmarkName(name string) error {
+	if name == "" {
+		return fmt.Errorf("bookmark name cannot be empty")
+	}
+	// 书签保存为配置文件的键，viper 会把含 . 的键拆成嵌套的映射
+	if strings.ContainsAny(name, "/\\@. \t") {
+		return fmt.Errorf("invalid bookmark name: %s (must not contain '/', '\\', '@', '.' or spaces)", name)
+	}
+	return nil
+}
+
+// warnLowercased 书签名被转换为小写时提示用户
+func warnLowercased(input, name string) {
+	if strings.TrimPrefix(strings.TrimSpace(input), "@") != name {
+		fmt.Printf("⚠️ Bookmark names are case-insensitive, saved as @%s\n", name)
+	}
+}
+
+// bookmarkNames 返回已排序的书签名
+func bookmarkNames() []string {
+	names := make([]string, 0, len(config.Bookmarks))
+	for name := range config.Bookmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// saveBookmarks 保存书签到配置文件
+func saveBookmarks() {
+	viper.Set("bookmarks", config.Bookmarks)
+	if err := writeConfig(); err != nil {
+		fmt.Printf("❌ Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// expandBookmark 将 @name 或 @name/sub/dir 展开为实际路径，其他路径原样返回
+// 以 @ 开头的真实路径（例如 node_modules 中的 @types/node）优先，没有同名书签时也原样返回
+func expandBookmark(p string) string {
+	if !strings.HasPrefix(p, "@") || isPathValid(p) || isPathValid(parseLocation(p).Path) {
+		return p
+	}
+
+	name, rest := p[1:], ""
+	if i := strings.IndexAny(name, "/\\"); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+
+	target, exists := config.Bookmarks[strings.ToLower(name)]
+	if !exists {
+		if debug {
+			fmt.Printf("🔍 No bookmark named @%s, using the literal path\n", name)
+		}
+		return p
+	}
+	if rest == "" {
+		return target
+	}
+	return filepath.Join(target, filepath.FromSlash(rest))
+}
+
+// completeBookmarkNames 补全书签名（用于 bm rm / bm rename）
+func completeBookmarkNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	loadConfig()
+
+	var names []string
+	for _, name := range bookmarkNames() {
+		if strings.HasPrefix(name, normalizeBookmarkName(toComplete)) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBookmarkPaths 补全以 @ 开头的路径参数，其他情况使用默认的文件补全
+func completeBookmarkPaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !strings.HasPrefix(toComplete, "@") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	loadConfig()
+
+	// @name/sub 形式：补全书签目录下的子路径
+	if i := strings.IndexAny(toComplete, "/\\"); i >= 0 {
+		expanded := expandBookmark(toComplete)
+		dir, prefix := filepath.Split(expanded)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		base := toComplete[:strings.LastIndexAny(toComplete, "/\\")+1]
+		var completions []string
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), prefix) {
+				continue
+			}
+			completion := base + entry.Name()
+			if entry.IsDir() {
+				completion += "/"
+			}
+			completions = append(completions, completion)
+		}
+		return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, name := range bookmarkNames() {
+		if strings.HasPrefix("@"+name, strings.ToLower(toComplete)) {
+			completions = append(completions, "@"+name+"/")
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	bmCmd.AddCommand(bmAddCmd)
+	bmCmd.AddCommand(bmRemoveCmd)
+	bmCmd.AddCommand(bmListCmd)
+	bmCmd.AddCommand(bmRenameCmd)
+	rootCmd.AddCommand(bmCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandBookmark(t *testing.T) {
+	project := t.TempDir()
+
+	// 在 node_modules 中运行时 @types/node 这类路径是真实存在的目录
+	modules := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(modules, "@types", "node"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modules, "@types", "node", "index.d.ts"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(modules); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	saved := config.Bookmarks
+	config.Bookmarks = map[string]string{"proj": project, "types": project}
+	t.Cleanup(func() { config.Bookmarks = saved })
+
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"@proj", project},
+		{"@PROJ", project},
+		{"@proj/src/main.go", filepath.Join(project, "src", "main.go")},
+		{"@types/node", "@types/node"},
+		{"@types/node/index.d.ts:12", "@types/node/index.d.ts:12"},
+		{"@types/missing", filepath.Join(project, "missing")},
+		{"@scope/pkg", "@scope/pkg"},
+		{"@unknown", "@unknown"},
+		{"plain/path", "plain/path"},
+	}
+
+	for _, tt := range tests {
+		if got := expandBookmark(tt.arg); got != tt.want {
+			t.Errorf("expandBookmark(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 剪切板后端。clipboard 配置为空或 auto 时按环境自动选择：
+//
+//	SSH 会话       osc52 -> tmux -> 本机命令（本机剪切板对用户不可见，优先写到本地终端）
+//	Wayland       wl-copy -> xclip -> xsel -> tmux -> osc52
+//	X11           xclip -> xsel -> wl-copy -> tmux -> osc52
+//	无图形界面     tmux -> osc52
+//	macOS         pbcopy
+//	Windows       clip.exe
+//
+// 配置为具体后端名称时只使用该后端。
+const (
+	clipboardAuto   = "auto"
+	clipboardPbcopy = "pbcopy"
+	clipboardClip   = "clip"
+	clipboardWl     = "wl-copy"
+	clipboardXclip  = "xclip"
+	clipboardXsel   = "xsel"
+	clipboardTmux   = "tmux"
+	clipboardOSC52  = "osc52"
+)
+
+var clipboardBackends = []string{clipboardAuto, clipboardPbcopy, clipboardClip, clipboardWl, clipboardXclip, clipboardXsel, clipboardTmux, clipboardOSC52}
+
+// clipboardCommands 通过外部命令写入剪切板的后端
+var clipboardCommands = map[string]Command{
+	clipboardPbcopy: {Name: "pbcopy"},
+	clipboardClip:   {Name: "clip.exe"},
+	clipboardWl:     {Name: "wl-copy"},
+	clipboardXclip:  {Name: "xclip", Args: []string{"-selection", "clipboard"}},
+	clipboardXsel:   {Name: "xsel", Args: []string{"--input", "--clipboard"}},
+	// -w 让 tmux 同时通过 set-clipboard 转发给外层终端
+	clipboardTmux: {Name: "tmux", Args: []string{"load-buffer", "-w", "-"}},
+}
+
+// clipboardPasteCommands 各后端读取剪切板的命令
+var clipboardPasteCommands = map[string]Command{
+	clipboardPbcopy: {Name: "pbpaste"},
+	clipboardClip:   {Name: "powershell.exe", Args: []string{"-NoProfile", "-Command", "Get-Clipboard"}},
+	clipboardWl:     {Name: "wl-paste", Args: []string{"--no-newline"}},
+	clipboardXclip:  {Name: "xclip", Args: []string{"-o", "-selection", "clipboard"}},
+	clipboardXsel:   {Name: "xsel", Args: []string{"--output", "--clipboard"}},
+	clipboardTmux:   {Name: "tmux", Args: []string{"save-buffer", "-"}},
+}
+
+// osc52QueryTimeout 等待终端回应 OSC 52 查询的时间，不支持查询的终端不会回应
+const osc52QueryTimeout = 500 * time.Millisecond
+
+// isSSHSession 判断是否在 SSH 会话中
+func isSSHSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// clipboardCandidates 返回按优先顺序排列的剪切板后端
+func clipboardCandidates() ([]string, error) {
+	forced := strings.ToLower(config.Clipboard)
+	if forced != "" && forced != clipboardAuto {
+		if !containsString(clipboardBackends, forced) {
+			return nil, fmt.Errorf("⚠️ unknown clipboard backend: %s (available: %s)", config.Clipboard, strings.Join(clipboardBackends, ", "))
+		}
+		return []string{forced}, nil
+	}
+
+	var local []string
+	switch runtime.GOOS {
+	case "darwin":
+		local = []string{clipboardPbcopy}
+	case "windows":
+		local = []string{clipboardClip}
+	default:
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			local = []string{clipboardWl, clipboardXclip, clipboardXsel}
+		case os.Getenv("DISPLAY") != "":
+			local = []string{clipboardXclip, clipboardXsel, clipboardWl}
+		}
+	}
+
+	inTmux := os.Getenv("TMUX") != ""
+	if isSSHSession() {
+		// 本机剪切板对用户不可见，优先通过 OSC 52 写到本地终端
+		candidates := []string{clipboardOSC52}
+		if inTmux {
+			candidates = append(candidates, clipboardTmux)
+		}
+		return append(candidates, local...), nil
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return local, nil
+	}
+	if inTmux {
+		local = append(local, clipboardTmux)
+	}
+	return append(local, clipboardOSC52), nil
+}
+
+// copyToClipboardPath 将路径复制到剪切板
+// 依次尝试 clipboardCandidates 返回的后端，直到有一个成功
+func copyToClipboardPath(path string) error {
+	candidates, err := clipboardCandidates()
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, name := range candidates {
+		err := writeClipboard(name, path)
+		if err == nil {
+			if debug {
+				fmt.Printf("🔍 Clipboard backend: %s\n", name)
+			}
+			return nil
+		}
+		if debug {
+			fmt.Printf("🔍 Clipboard backend %s failed: %v\n", name, err)
+		}
+		failures = append(failures, name)
+	}
+
+	return fmt.Errorf("⚠️ no clipboard backend succeeded (tried: %s)", strings.Join(failures, ", "))
+}
+
+// writeClipboard 使用指定后端写入剪切板
+func writeClipboard(name, text string) error {
+	if name == clipboardOSC52 {
+		return writeOSC52(text)
+	}
+
+	cmd, exists := clipboardCommands[name]
+	if !exists {
+		return fmt.Errorf("⚠️ unknown clipboard backend: %s", name)
+	}
+	if name == clipboardTmux && os.Getenv("TMUX") == "" {
+		return fmt.Errorf("⚠️ not running inside tmux")
+	}
+	cmd.Stdin = text
+	return launcher.Launch(cmd)
+}
+
+// readClipboard 读取剪切板中的文本
+// 按与写入相同的顺序尝试各个后端，返回第一个非空的结果
+func readClipboard() (string, error) {
+	candidates, err := clipboardCandidates()
+	if err != nil {
+		return "", err
+	}
+
+	var failures []string
+	for _, name := range candidates {
+		text, err := readClipboardBackend(name)
+		if err == nil && text != "" {
+			if debug {
+				fmt.Printf("🔍 Clipboard backend: %s\n", name)
+			}
+			return text, nil
+		}
+		if debug {
+			if err == nil {
+				err = fmt.Errorf("clipboard is empty")
+			}
+			fmt.Printf("🔍 Clipboard backend %s failed: %v\n", name, err)
+		}
+		failures = append(failures, name)
+	}
+
+	return "", fmt.Errorf("⚠️ cannot read the clipboard (tried: %s)", strings.Join(failures, ", "))
+}
+
+// readClipboardBackend 使用指定后端读取剪切板
+func readClipboardBackend(name string) (string, error) {
+	if name == clipboardOSC52 {
+		return queryOSC52()
+	}
+
+	cmd, exists := clipboardPasteCommands[name]
+	if !exists {
+		return "", fmt.Errorf("⚠️ unknown clipboard backend: %s", name)
+	}
+	if name == clipboardTmux && os.Getenv("TMUX") == "" {
+		return "", fmt.Errorf("⚠️ not running inside tmux")
+	}
+	text, err := launcher.Output(cmd)
+	if name == clipboardClip {
+		// Get-Clipboard 的输出以 CRLF 结尾
+		text = strings.TrimRight(text, "\r\n")
+	}
+	return text, err
+}
+
+// queryOSC52 通过 OSC 52 查询终端的剪切板
+// 只有部分终端支持（例如 kitty、foot、WezTerm，xterm 需要开启 allowWindowOps），
+// 不支持的终端不会回应，超时后返回错误
+func queryOSC52() (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("⚠️ OSC 52 query is not supported on Windows")
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("⚠️ no terminal available: %v", err)
+	}
+	defer tty.Close()
+
+	// 原始模式：终端的回应不会回显，也不需要等待回车
+	// makeRaw 调用 Fd() 会把文件切换为阻塞模式，读超时随之失效，因此使用单独打开的句柄
+	ctl, err := os.Open("/dev/tty")
+	if err != nil {
+		return "", fmt.Errorf("⚠️ no terminal available: %v", err)
+	}
+	defer ctl.Close()
+	restore, err := makeRaw(ctl)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	if err := tty.SetReadDeadline(time.Now().Add(osc52QueryTimeout)); err != nil {
+		return "", fmt.Errorf("⚠️ cannot wait for the terminal: %v", err)
+	}
+	if _, err := tty.WriteString(wrapPassthrough("\x1b]52;c;?\a")); err != nil {
+		return "", err
+	}
+
+	// 回应格式：ESC ] 52 ; c ; <base64> BEL（或 ESC \）
+	var reply []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := tty.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if i := bytes.Index(reply, []byte("\x1b]52;")); i >= 0 {
+			rest := reply[i+1:]
+			if bytes.IndexByte(rest, '\a') >= 0 || bytes.Contains(rest, []byte("\x1b\\")) {
+				return decodeOSC52Reply(reply[i:])
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("⚠️ terminal did not answer the OSC 52 query")
+		}
+	}
+}
+
+// decodeOSC52Reply 解析终端对 OSC 52 查询的回应
+func decodeOSC52Reply(reply []byte) (string, error) {
+	body := strings.TrimPrefix(string(reply), "\x1b]52;")
+	if end := strings.IndexAny(body, "\a\x1b"); end >= 0 {
+		body = body[:end]
+	}
+	// 去掉选择区域（c;、p; 等）
+	if i := strings.IndexByte(body, ';'); i >= 0 {
+		body = body[i+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("⚠️ invalid OSC 52 reply: %v", err)
+	}
+	return string(data), nil
+}
+
+// osc52Sequence 返回写入剪切板的 OSC 52 序列，在 tmux/screen 中包装为 passthrough
+func osc52Sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	return wrapPassthrough(seq)
+}
+
+// wrapPassthrough 让转义序列穿过 tmux/screen 到达外层终端
+// tmux 需要开启 allow-passthrough（3.3 起默认关闭）
+func wrapPassthrough(seq string) string {
+	switch {
+	case os.Getenv("TMUX") != "":
+		// DCS tmux; <序列中的 ESC 加倍> ST
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case os.Getenv("STY") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		// screen 的 DCS 有长度限制，按块分别包装
+		const chunk = 76
+		var b strings.Builder
+		for i := 0; i < len(seq); i += chunk {
+			end := i + chunk
+			if end > len(seq) {
+				end = len(seq)
+			}
+			b.WriteString("\x1bP" + seq[i:end] + "\x1b\\")
+		}
+		return b.String()
+	}
+	return seq
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -27,43 +28,116 @@ var configShowCmd = &cobra.Command{
 
 		fmt.Printf("📁 Config file: %s\n", configFile)
 		fmt.Printf("🔧 Default manager: %s\n", config.DefaultManager)
-		fmt.Printf("📊 Recent paths count: %d\n", len(config.RecentPaths))
+		if config.Opener != "" {
+			fmt.Printf("🚪 Opener: %s\n", config.Opener)
+		} else {
+			fmt.Printf("🚪 Opener: auto\n")
+		}
+		fmt.Printf("🖥️ Headless fallback: %s\n", headlessPolicy())
+		if config.HeadlessCommand != "" {
+			fmt.Printf("🖥️ Headless command: %s\n", config.HeadlessCommand)
+		}
+		fmt.Printf("📋 Clipboard: %s\n", valueOrNone(config.Clipboard))
+		fmt.Printf("📡 Remote address: %s\n", remoteAddress())
+		fmt.Printf("📊 History entries: %d\n", len(loadHistory()))
 		fmt.Printf("📈 Max recent paths: %d\n", config.MaxRecent)
 
 		if len(config.CustomManagers) > 0 {
 			fmt.Println("🔧 Custom managers:")
-			for name, cmd := range config.CustomManagers {
-				fmt.Printf("  %s: %s\n", name, cmd)
+			for name, m := range config.CustomManagers {
+				if m.Terminal {
+					fmt.Printf("  %s: %s (terminal)\n", name, m.Command)
+				} else {
+					fmt.Printf("  %s: %s\n", name, m.Command)
+				}
+			}
+		}
+
+		if len(config.Bookmarks) > 0 {
+			fmt.Println("🔖 Bookmarks:")
+			for _, name := range bookmarkNames() {
+				fmt.Printf("  @%s: %s\n", name, config.Bookmarks[name])
 			}
 		}
 
 		if len(config.FileTypeApps) > 0 {
 			fmt.Println("📄 File type applications:")
-			for ext, app := range config.FileTypeApps {
-				fmt.Printf("  .%s: %s\n", ext, app)
+			for key, app := range config.FileTypeApps {
+				fmt.Printf("  %s: %s\n", formatFileTypeKey(key), app)
+			}
+		}
+
+		if len(config.FileGroups) > 0 {
+			fmt.Println("📂 File groups (see: of config group ls):")
+			groups := fileGroups()
+			for _, name := range fileGroupNames() {
+				if _, configured := config.FileGroups[name]; configured {
+					fmt.Printf("  %s: %s\n", name, strings.Join(groups[name], ", "))
+				}
+			}
+		}
+
+		if len(config.SchemeHandlers) > 0 {
+			fmt.Println("🌐 Scheme handlers:")
+			for _, scheme := range schemeHandlerNames() {
+				fmt.Printf("  %s: %s\n", scheme, config.SchemeHandlers[scheme])
+			}
+		}
+
+		if len(config.GitForges) > 0 {
+			fmt.Println("🐙 Git forges:")
+			for forge, hosts := range config.GitForges {
+				fmt.Printf("  %s: %s\n", forge, strings.Join(hosts, ", "))
+			}
+		}
+
+		if len(config.EditorArgs) > 0 {
+			fmt.Println("📝 Editor line arguments:")
+			for editor, args := range config.EditorArgs {
+				fmt.Printf("  %s: %s\n", editor, args)
 			}
 		}
 	},
 }
 
+var addManagerTerminal bool
+
 var configAddManagerCmd = &cobra.Command{
 	Use:   "add-manager [name] [command]",
 	Short: "add custom file manager",
-	Args:  cobra.ExactArgs(2),
+	Long: `Add a custom file manager or application.
+
+The command may be a full template with shell-style quoting and placeholders:
+  {path} {dir} {name} {ext} {uri} {line} {col}
+
+Terminal file managers (yazi, lf, ranger, nnn...) need --terminal so they
+run attached to the current terminal instead of in the background.
+
+Examples:
+  of config add-manager vscode "code --reuse-window {path}"
+  of config add-manager nautilus "nautilus --select {path}"
+  of config add-manager vim "gvim +{line} {path}"
+  of config add-manager --terminal yazi "yazi {path}"`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
 		name := args[0]
 		command := args[1]
 
+		if _, err := splitCommandLine(command); err != nil {
+			fmt.Printf("❌ Invalid command: %v\n", err)
+			os.Exit(1)
+		}
+
 		if config.CustomManagers == nil {
-			config.CustomManagers = make(map[string]string)
+			config.CustomManagers = make(map[string]managerConfig)
 		}
 
-		config.CustomManagers[name] = command
-		viper.Set("custom_managers", config.CustomManagers)
+		config.CustomManagers[name] = managerConfig{Command: command, Terminal: addManagerTerminal}
+		viper.Set("custom_managers", managerConfigValues())
 
-		if err := viper.WriteConfig(); err != nil {
+		if err := writeConfig(); err != nil {
 			fmt.Printf("❌ Error saving config: %v\n", err)
 			os.Exit(1)
 		}
@@ -75,7 +149,13 @@ var configAddManagerCmd = &cobra.Command{
 var configSetDefaultCmd = &cobra.Command{
 	Use:   "set-default [manager]",
 	Short: "set default file manager",
-	Args:  cobra.ExactArgs(1),
+	Long: `Set the default file manager.
+
+Use "auto" to open the platform file manager in a graphical session and a
+terminal file manager (terminal_manager, a custom manager marked --terminal,
+or yazi/lf/ranger/nnn... found in PATH) when DISPLAY and WAYLAND_DISPLAY are
+both unset.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
@@ -83,7 +163,7 @@ var configSetDefaultCmd = &cobra.Command{
 		config.DefaultManager = managerName
 		viper.Set("default_manager", managerName)
 
-		if err := viper.WriteConfig(); err != nil {
+		if err := writeConfig(); err != nil {
 			fmt.Printf("❌ Error saving config: %v\n", err)
 			os.Exit(1)
 		}
@@ -92,17 +172,53 @@ var configSetDefaultCmd = &cobra.Command{
 	},
 }
 
+var configSetOpenerCmd = &cobra.Command{
+	Use:   "set-opener [name]",
+	Short: "set opener backend (use \"auto\" for auto-detection)",
+	Long: `Set the backend used to open paths with the system default application.
+
+Registered openers:
+  xdg-open, gio, kioclient5, kioclient, exo-open   (Linux/BSD)
+  open                                             (macOS)
+  explorer, start                                  (Windows)
+  auto                                             detect from platform and desktop`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := args[0]
+		if name == "auto" {
+			name = ""
+		} else if _, exists := openers[name]; !exists {
+			fmt.Printf("❌ Unknown opener: %s\n", name)
+			fmt.Printf("Available openers: %s\n", strings.Join(openerNames(), ", "))
+			os.Exit(1)
+		}
+
+		config.Opener = name
+		viper.Set("opener", name)
+
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if name == "" {
+			fmt.Println("✅ Opener set to auto-detection")
+		} else {
+			fmt.Printf("✅ Set opener: %s\n", name)
+		}
+	},
+}
+
 var configClearRecentCmd = &cobra.Command{
 	Use:   "clear-recent",
 	Short: "clear recent paths",
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
-		config.RecentPaths = []string{}
-		viper.Set("recent_paths", config.RecentPaths)
-
-		if err := viper.WriteConfig(); err != nil {
-			fmt.Printf("❌ Error saving config: %v\n", err)
+		if err := clearHistory(); err != nil {
+			fmt.Printf("❌ Error clearing recent paths: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -111,18 +227,31 @@ var configClearRecentCmd = &cobra.Command{
 }
 
 var configAddFileTypeCmd = &cobra.Command{
-	Use:   "add-filetype [extension] [app]",
+	Use:   "add-filetype [extension|mime] [app]",
 	Short: "add file type application mapping",
-	Args:  cobra.ExactArgs(2),
+	Long: `Add file type application mapping.
+
+The key may be a file extension, a MIME type, a MIME wildcard or a file group:
+  of config add-filetype md typora
+  of config add-filetype text/x-dockerfile code
+  of config add-filetype "image/*" Preview
+  of config add-filetype group:audio IINA`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
-		ext := strings.ToLower(strings.TrimPrefix(args[0], "."))
+		key := normalizeFileTypeKey(args[0])
 		app := args[1]
 
+		if name, ok := fileGroupName(key); ok {
+			if _, exists := fileGroups()[name]; !exists {
+				fmt.Printf("❌ Unknown file group: %s (see: of config group ls)\n", name)
+				os.Exit(1)
+			}
+		}
+
 		// 验证应用程序是否存在
-		exists, message := validateApp(app)
-		if !exists {
+		if exists, message := validateAppTemplate(app); !exists {
 			fmt.Printf("❌ %s\n", message)
 			os.Exit(1)
 		}
@@ -131,46 +260,46 @@ var configAddFileTypeCmd = &cobra.Command{
 			config.FileTypeApps = make(map[string]string)
 		}
 
-		config.FileTypeApps[ext] = app
+		config.FileTypeApps[key] = app
 		viper.Set("file_type_apps", config.FileTypeApps)
 
-		if err := viper.WriteConfig(); err != nil {
+		if err := writeConfig(); err != nil {
 			fmt.Printf("❌ Error saving config: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("✅ Added file type mapping: .%s -> %s\n", ext, app)
+		fmt.Printf("✅ Added file type mapping: %s -> %s\n", formatFileTypeKey(key), app)
 	},
 }
 
 var configRemoveFileTypeCmd = &cobra.Command{
-	Use:   "remove-filetype [extension]",
+	Use:   "remove-filetype [extension|mime]",
 	Short: "remove file type application mapping",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
-		ext := strings.ToLower(strings.TrimPrefix(args[0], "."))
+		key := normalizeFileTypeKey(args[0])
 
 		if config.FileTypeApps == nil {
 			fmt.Printf("❌ No file type mappings found\n")
 			os.Exit(1)
 		}
 
-		if _, exists := config.FileTypeApps[ext]; !exists {
-			fmt.Printf("❌ File type .%s not found in mappings\n", ext)
+		if _, exists := config.FileTypeApps[key]; !exists {
+			fmt.Printf("❌ File type %s not found in mappings\n", formatFileTypeKey(key))
 			os.Exit(1)
 		}
 
-		delete(config.FileTypeApps, ext)
+		delete(config.FileTypeApps, key)
 		viper.Set("file_type_apps", config.FileTypeApps)
 
-		if err := viper.WriteConfig(); err != nil {
+		if err := writeConfig(); err != nil {
 			fmt.Printf("❌ Error saving config: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("✅ Removed file type mapping: .%s\n", ext)
+		fmt.Printf("✅ Removed file type mapping: %s\n", formatFileTypeKey(key))
 	},
 }
 
@@ -186,8 +315,196 @@ var configListFileTypesCmd = &cobra.Command{
 		}
 
 		fmt.Println("📄 File type mappings:")
-		for ext, app := range config.FileTypeApps {
-			fmt.Printf("  .%s -> %s\n", ext, app)
+		for key, app := range config.FileTypeApps {
+			fmt.Printf("  %s -> %s\n", formatFileTypeKey(key), app)
+		}
+	},
+}
+
+var configSetEditorArgsCmd = &cobra.Command{
+	Use:   "set-editor-args [editor] [args]",
+	Short: "set how an editor is told to jump to a line",
+	Long: `Set the arguments used when opening path:line:col with an editor.
+
+The editor is the program name (e.g. code, vim, idea). The arguments are a
+command template without the program itself; an empty string disables
+line support for that editor.
+
+Examples:
+  of config set-editor-args code "--goto {path}:{line}:{col}"
+  of config set-editor-args gvim "+{line} {path}"
+  of config set-editor-args idea "--line {line} --column {col} {path}"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		editor := editorProgramName(args[0])
+		lineArgs := args[1]
+
+		if lineArgs != "" {
+			if _, err := splitCommandLine(lineArgs); err != nil {
+				fmt.Printf("❌ Invalid arguments: %v\n", err)
+				os.Exit(1)
+			}
+			if !strings.Contains(lineArgs, "{line}") {
+				fmt.Printf("⚠️ Warning: arguments do not contain {line}\n")
+			}
+		}
+
+		if config.EditorArgs == nil {
+			config.EditorArgs = make(map[string]string)
+		}
+		config.EditorArgs[editor] = lineArgs
+		viper.Set("editor_args", config.EditorArgs)
+
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if lineArgs == "" {
+			fmt.Printf("✅ Disabled line arguments for: %s\n", editor)
+		} else {
+			fmt.Printf("✅ Set editor arguments: %s -> %s\n", editor, lineArgs)
+		}
+	},
+}
+
+var configListEditorsCmd = &cobra.Command{
+	Use:   "list-editors",
+	Short: "list editor line argument conventions",
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		fmt.Println("📝 Editor line arguments:")
+		for _, name := range editorArgNames() {
+			lineArgs, ok := editorArgs(name)
+			switch {
+			case !ok:
+				fmt.Printf("  %s -> (disabled)\n", name)
+			case config.EditorArgs[name] != "":
+				fmt.Printf("  %s -> %s (custom)\n", name, lineArgs)
+			default:
+				fmt.Printf("  %s -> %s\n", name, lineArgs)
+			}
+		}
+	},
+}
+
+var configSetSchemeHandlerCmd = &cobra.Command{
+	Use:   "set-scheme-handler [scheme] [app]",
+	Short: "set the app used to open URLs with a scheme",
+	Long: `Set the app used to open URLs with the given scheme.
+
+The app may be an application name, a custom manager or a command template
+({url} expands to the full URL). An empty app removes the handler so the
+platform opener is used again.
+
+Examples:
+  of config set-scheme-handler https firefox
+  of config set-scheme-handler ssh "kitty ssh {url}"
+  of config set-scheme-handler mailto ""`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		scheme := strings.ToLower(strings.TrimSuffix(args[0], ":"))
+		app := args[1]
+
+		if !schemePattern.MatchString(scheme + ":") {
+			fmt.Printf("❌ Invalid scheme: %s\n", scheme)
+			os.Exit(1)
+		}
+		if scheme == "file" {
+			fmt.Printf("❌ file:// URLs are opened as local paths, use add-filetype instead\n")
+			os.Exit(1)
+		}
+
+		if config.SchemeHandlers == nil {
+			config.SchemeHandlers = make(map[string]string)
+		}
+		if app == "" {
+			delete(config.SchemeHandlers, scheme)
+		} else {
+			if _, err := splitCommandLine(app); err != nil {
+				fmt.Printf("❌ Invalid command: %v\n", err)
+				os.Exit(1)
+			}
+			config.SchemeHandlers[scheme] = app
+		}
+		viper.Set("scheme_handlers", config.SchemeHandlers)
+
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if app == "" {
+			fmt.Printf("✅ Removed scheme handler: %s\n", scheme)
+		} else {
+			fmt.Printf("✅ Set scheme handler: %s -> %s\n", scheme, app)
+		}
+	},
+}
+
+var configSetHeadlessCmd = &cobra.Command{
+	Use:   "set-headless [policy] [command]",
+	Short: "set what to do when there is no graphical session",
+	Long: `Set what happens instead of launching the platform opener when running
+over SSH (SSH_CONNECTION/SSH_TTY) or without DISPLAY and WAYLAND_DISPLAY.
+
+Policies:
+  print      print the path (default)
+  osc52      copy the path to the local clipboard with OSC 52
+  terminal   run command in the current terminal ({path} placeholders allowed)
+  hyperlink  print a clickable OSC 8 hyperlink
+  none       always use the platform opener
+
+Examples:
+  of config set-headless osc52
+  of config set-headless terminal "less {path}"`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return headlessPolicies, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		policy := strings.ToLower(args[0])
+		if !containsString(headlessPolicies, policy) {
+			fmt.Printf("❌ Unknown policy: %s (available: %s)\n", policy, strings.Join(headlessPolicies, ", "))
+			os.Exit(1)
+		}
+
+		command := ""
+		if len(args) > 1 {
+			command = args[1]
+		}
+		if policy == headlessTerminal {
+			if command == "" {
+				fmt.Printf("❌ The terminal policy needs a command, e.g. \"less {path}\"\n")
+				os.Exit(1)
+			}
+			if _, err := splitCommandLine(command); err != nil {
+				fmt.Printf("❌ Invalid command: %v\n", err)
+				os.Exit(1)
+			}
+			viper.Set("headless_command", command)
+		}
+		viper.Set("headless_fallback", policy)
+
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if command != "" {
+			fmt.Printf("✅ Set headless fallback: %s (%s)\n", policy, command)
+		} else {
+			fmt.Printf("✅ Set headless fallback: %s\n", policy)
 		}
 	},
 }
@@ -197,46 +514,35 @@ var configAddFileGroupCmd = &cobra.Command{
 	Short: "add file type group application mapping",
 	Long: `Add file type group application mapping.
 
-Available groups:
-  audio     - mp3, wav, flac, aac, ogg, m4a, wma
-  video     - mp4, avi, mkv, mov, wmv, flv, webm, m4v, 3gp
-  image     - jpg, jpeg, png, gif, bmp, svg, tiff, webp
-  document  - pdf, doc, docx, txt, md, rtf
-  code      - py, js, ts, go, java, cpp, c, h, html, css, json, xml, yaml, yml
-  archive   - zip, rar, 7z, tar, gz, bz2
-  spreadsheet - xls, xlsx, csv
-  presentation - ppt, pptx`,
-	Args: cobra.ExactArgs(2),
+The mapping is saved as group:<name> in file_type_apps and follows the group,
+so extensions added to the group later open with the same app. Extensions
+mapped on their own (add-filetype) take precedence over groups.
+
+Older versions saved one entry per extension instead. Those entries are
+replaced when they use the new app or map the whole group to one app;
+other per-extension entries are kept and reported.
+
+Built-in groups: audio, video, image, document, code, archive, spreadsheet,
+presentation. List, inspect and edit groups with "of config group".`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFileGroupNames,
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
-		group := strings.ToLower(args[0])
+		group := normalizeFileGroupName(args[0])
 		app := args[1]
 
 		// 验证应用程序是否存在
-		exists, message := validateApp(app)
-		if !exists {
+		if exists, message := validateAppTemplate(app); !exists {
 			fmt.Printf("❌ %s\n", message)
 			os.Exit(1)
 		}
 
-		// 定义文件类型组
-		fileGroups := map[string][]string{
-			"audio":        {"mp3", "wav", "flac", "aac", "ogg", "m4a", "wma"},
-			"video":        {"mp4", "avi", "mkv", "mov", "wmv", "flv", "webm", "m4v", "3gp"},
-			"image":        {"jpg", "jpeg", "png", "gif", "bmp", "svg", "tiff", "webp"},
-			"document":     {"pdf", "doc", "docx", "txt", "md", "rtf"},
-			"code":         {"py", "js", "ts", "go", "java", "cpp", "c", "h", "html", "css", "json", "xml", "yaml", "yml"},
-			"archive":      {"zip", "rar", "7z", "tar", "gz", "bz2"},
-			"spreadsheet":  {"xls", "xlsx", "csv"},
-			"presentation": {"ppt", "pptx"},
-		}
-
-		extensions, exists := fileGroups[group]
+		extensions, exists := fileGroups()[group]
 		if !exists {
 			fmt.Printf("❌ Unknown file group: %s\n", group)
 			fmt.Println("Available groups:")
-			for g := range fileGroups {
+			for _, g := range fileGroupNames() {
 				fmt.Printf("  %s\n", g)
 			}
 			os.Exit(1)
@@ -246,33 +552,337 @@ Available groups:
 			config.FileTypeApps = make(map[string]string)
 		}
 
-		// 添加所有扩展名
-		count := 0
-		for _, ext := range extensions {
-			config.FileTypeApps[ext] = app
-			count++
-		}
+		key := fileGroupKey(group)
+		config.FileTypeApps[key] = app
+		removed, shadowed := collapseFileGroupEntries(extensions, app)
 
 		viper.Set("file_type_apps", config.FileTypeApps)
 
-		if err := viper.WriteConfig(); err != nil {
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Added file group mapping: %s (%d file types) -> %s\n", key, len(extensions), app)
+		fmt.Printf("📄 Extensions: %s\n", strings.Join(extensions, ", "))
+		if len(removed) > 0 {
+			fmt.Printf("🧹 Replaced per-extension mappings: %s\n", strings.Join(removed, ", "))
+		}
+		for _, ext := range shadowed {
+			fmt.Printf("⚠️ .%s still uses %s (file_type_apps.%s takes precedence; remove it with: of config remove-filetype %s)\n", ext, config.FileTypeApps[ext], ext, ext)
+		}
+	},
+}
+
+// normalizeFileTypeKey 规范化 file_type_apps 的键：扩展名去掉点号，统一小写
+func normalizeFileTypeKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if isMIMEKey(key) {
+		return key
+	}
+	return strings.TrimPrefix(key, ".")
+}
+
+var configSetClipboardCmd = &cobra.Command{
+	Use:   "set-clipboard [backend]",
+	Short: "set the clipboard backend used by --copy",
+	Long: `Set the clipboard backend used by --copy.
+
+Backends:
+  auto     choose from the environment (default): OSC 52 first over SSH,
+           wl-copy on Wayland, xclip/xsel on X11, tmux and OSC 52 otherwise
+  pbcopy   macOS
+  clip     Windows clip.exe
+  wl-copy  Wayland
+  xclip    X11
+  xsel     X11
+  tmux     tmux load-buffer (also forwarded by tmux set-clipboard)
+  osc52    terminal escape sequence, works over SSH; wrapped for tmux/screen
+
+Examples:
+  of config set-clipboard osc52
+  of config set-clipboard auto`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return clipboardBackends, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		backend := strings.ToLower(args[0])
+		if !containsString(clipboardBackends, backend) {
+			fmt.Printf("❌ Unknown clipboard backend: %s (available: %s)\n", backend, strings.Join(clipboardBackends, ", "))
+			os.Exit(1)
+		}
+
+		viper.Set("clipboard", backend)
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Set clipboard backend: %s\n", backend)
+	},
+}
+
+var configSetForgeCmd = &cobra.Command{
+	Use:   "set-forge [host] [forge]",
+	Short: "set which forge a self-hosted Git host runs, for --web",
+	Long: `Tell --web which forge a Git host runs so it can build browse URLs.
+Hosts containing github, gitlab, bitbucket, gitea or forgejo, and codeberg.org,
+are recognized automatically. An empty forge removes the host.
+
+Forges: github, gitlab, gitea, bitbucket
+
+Examples:
+  of config set-forge git.example.com gitlab
+  of config set-forge git.example.com ""`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return gitForges, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		host, forge := strings.ToLower(args[0]), strings.ToLower(args[1])
+		if forge != "" && !containsString(gitForges, forge) {
+			fmt.Printf("❌ Unknown forge: %s (available: %s)\n", forge, strings.Join(gitForges, ", "))
+			os.Exit(1)
+		}
+
+		// 先从所有平台中移除该主机，再加入指定的平台
+		forges := map[string][]string{}
+		for name, hosts := range config.GitForges {
+			for _, h := range hosts {
+				if !strings.EqualFold(h, host) {
+					forges[name] = append(forges[name], h)
+				}
+			}
+		}
+		if forge != "" {
+			forges[forge] = append(forges[forge], host)
+		}
+
+		viper.Set("git_forges", forges)
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if forge == "" {
+			fmt.Printf("✅ Removed forge for host: %s\n", host)
+		} else {
+			fmt.Printf("✅ Set forge for %s: %s\n", host, forge)
+		}
+	},
+}
+
+var newRule appRule
+var newRulePosition int
+
+// ruleDirectories add-rule/remove-rule 的 --dir：操作 directory_rules
+var ruleDirectories bool
+
+// selectedRules 返回 --dir 选择的规则列表及其配置键
+func selectedRules() (string, []appRule) {
+	if ruleDirectories {
+		return "directory_rules", config.DirectoryRules
+	}
+	return "rules", config.Rules
+}
+
+var configAddRuleCmd = &cobra.Command{
+	Use:   "add-rule [app]",
+	Short: "add an app selection rule",
+	Long: `Add a rule to the ordered rules list. All given conditions must match and
+the first matching rule wins; file_type_apps is only used when no rule matches.
+
+With --dir the rule is added to directory_rules and decides which app opens a
+folder instead of the file manager (only --glob, --regex and --markers apply).
+Markers are searched from the folder upward, up to marker_depth levels.
+
+Examples:
+  of config add-rule goland --glob "*_test.go" --name "go tests"
+  of config add-rule obsidian --glob "~/notes/**"
+  of config add-rule code --ext go --markers go.mod
+  of config add-rule gimp --mime "image/*" --size ">20MB"
+  of config add-rule vim --regex "/etc/.*\.conf$" --position 1
+  of config add-rule code --dir --markers go.mod,Cargo.toml,package.json
+  of config add-rule xcode --dir --markers "*.xcodeproj"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		rule := newRule
+		rule.App = args[0]
+		validate := rule.validate
+		if ruleDirectories {
+			validate = rule.validateDirectory
+		}
+		if err := validate(); err != nil {
+			fmt.Printf("❌ Invalid rule: %v\n", err)
+			os.Exit(1)
+		}
+		if exists, message := validateAppTemplate(rule.App); !exists {
+			fmt.Printf("❌ %s\n", message)
+			os.Exit(1)
+		}
+
+		// --position 从 1 开始，超出范围时追加到末尾
+		key, existing := selectedRules()
+		rules := append([]appRule{}, existing...)
+		index := len(rules)
+		if newRulePosition > 0 && newRulePosition <= len(rules) {
+			index = newRulePosition - 1
+		}
+		rules = append(rules[:index], append([]appRule{rule}, rules[index:]...)...)
+
+		viper.Set(key, ruleConfigValues(rules))
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		kind := "rule"
+		if ruleDirectories {
+			kind = "directory rule"
+		}
+		fmt.Printf("✅ Added %s %d: %s -> %s\n", kind, index+1, rule.conditions(), rule.App)
+	},
+}
+
+var configRemoveRuleCmd = &cobra.Command{
+	Use:   "remove-rule [number]",
+	Short: "remove an app selection rule",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		key, existing := selectedRules()
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(existing) {
+			fmt.Printf("❌ No rule number %s in %s (see: of config list-rules)\n", args[0], key)
+			os.Exit(1)
+		}
+
+		removed := existing[n-1]
+		rules := append(append([]appRule{}, existing[:n-1]...), existing[n:]...)
+		viper.Set(key, ruleConfigValues(rules))
+		if err := writeConfig(); err != nil {
+			fmt.Printf("❌ Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+
+		kind := "rule"
+		if ruleDirectories {
+			kind = "directory rule"
+		}
+		fmt.Printf("✅ Removed %s %d: %s -> %s\n", kind, n, removed.conditions(), removed.App)
+	},
+}
+
+var configListRulesCmd = &cobra.Command{
+	Use:   "list-rules",
+	Short: "list app selection rules in match order",
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		if len(config.Rules) == 0 && len(config.DirectoryRules) == 0 {
+			fmt.Println("📋 No rules configured")
+			return
+		}
+
+		if len(config.Rules) > 0 {
+			fmt.Println("📋 Rules (first match wins, then file_type_apps):")
+			for i, rule := range config.Rules {
+				printRule(i, rule, rule.validate())
+			}
+		}
+		if len(config.DirectoryRules) > 0 {
+			fmt.Printf("📁 Directory rules (first match wins, then file manager; markers searched %d levels up):\n", markerDepth())
+			for i, rule := range config.DirectoryRules {
+				printRule(i, rule, rule.validateDirectory())
+			}
+		}
+	},
+}
+
+// printRule 输出 list-rules 中的一条规则，规则无效时附上原因
+func printRule(index int, rule appRule, err error) {
+	name := ""
+	if rule.Name != "" {
+		name = " [" + rule.Name + "]"
+	}
+	status := ""
+	if err != nil {
+		status = fmt.Sprintf("  ⚠️ %v", err)
+	}
+	fmt.Printf("  %d.%s %s -> %s%s\n", index+1, name, rule.conditions(), rule.App, status)
+}
+
+var configSetMarkerDepthCmd = &cobra.Command{
+	Use:   "set-marker-depth [levels]",
+	Short: "set how many parent folders are searched for rule markers",
+	Long: `Set how many levels above a file or folder are searched for the markers of
+rules and directory_rules (0 = only the folder itself, default 5).
+
+Example:
+  of config set-marker-depth 2`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		depth, err := strconv.Atoi(args[0])
+		if err != nil || depth < 0 {
+			fmt.Printf("❌ Invalid depth: %s (must be 0 or more)\n", args[0])
+			os.Exit(1)
+		}
+
+		viper.Set("marker_depth", depth)
+		if err := writeConfig(); err != nil {
 			fmt.Printf("❌ Error saving config: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("✅ Added file group mapping: %s (%d file types) -> %s\n", group, count, app)
-		fmt.Printf("📄 Added extensions: %s\n", strings.Join(extensions, ", "))
+		fmt.Printf("✅ Marker depth set to: %d\n", depth)
 	},
 }
 
 func init() {
+	configAddRuleCmd.Flags().StringVar(&newRule.Name, "name", "", "rule name shown in list-rules and explain")
+	configAddRuleCmd.Flags().StringSliceVar(&newRule.Glob, "glob", nil, "path glob (file name unless it contains /; supports ~ and **)")
+	configAddRuleCmd.Flags().StringVar(&newRule.Regex, "regex", "", "regular expression matched against the full path")
+	configAddRuleCmd.Flags().StringSliceVar(&newRule.Ext, "ext", nil, "file extensions")
+	configAddRuleCmd.Flags().StringSliceVar(&newRule.MIME, "mime", nil, "MIME types, wildcards like image/* allowed")
+	configAddRuleCmd.Flags().StringVar(&newRule.Size, "size", "", "size range: >10MB, <=1KB, 1KB-1MB")
+	configAddRuleCmd.Flags().StringSliceVar(&newRule.Markers, "markers", nil, "files that must exist in the file's directory or a parent (go.mod, *.xcodeproj)")
+	configAddRuleCmd.Flags().IntVar(&newRulePosition, "position", 0, "insert at this position (1 = highest priority, default: last)")
+	configAddRuleCmd.Flags().BoolVar(&ruleDirectories, "dir", false, "add to directory_rules (apps for opening folders)")
+	configRemoveRuleCmd.Flags().BoolVar(&ruleDirectories, "dir", false, "remove from directory_rules")
 	configCmd.AddCommand(configShowCmd)
+	configAddManagerCmd.Flags().BoolVarP(&addManagerTerminal, "terminal", "t", false, "run the manager attached to the current terminal")
 	configCmd.AddCommand(configAddManagerCmd)
 	configCmd.AddCommand(configSetDefaultCmd)
+	configCmd.AddCommand(configSetOpenerCmd)
 	configCmd.AddCommand(configClearRecentCmd)
 	configCmd.AddCommand(configAddFileTypeCmd)
 	configCmd.AddCommand(configRemoveFileTypeCmd)
 	configCmd.AddCommand(configListFileTypesCmd)
 	configCmd.AddCommand(configAddFileGroupCmd)
+	configCmd.AddCommand(configSetEditorArgsCmd)
+	configCmd.AddCommand(configListEditorsCmd)
+	configCmd.AddCommand(configSetSchemeHandlerCmd)
+	configCmd.AddCommand(configSetHeadlessCmd)
+	configCmd.AddCommand(configSetClipboardCmd)
+	configCmd.AddCommand(configSetForgeCmd)
+	configCmd.AddCommand(configAddRuleCmd)
+	configCmd.AddCommand(configRemoveRuleCmd)
+	configCmd.AddCommand(configListRulesCmd)
+	configCmd.AddCommand(configSetMarkerDepthCmd)
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// --copy-format 支持的格式
+const (
+	copyFormatPath     = "path"     // 绝对路径（默认）
+	copyFormatQuoted   = "quoted"   // 加上 shell 引号的路径
+	copyFormatURI      = "uri"      // file:// URI
+	copyFormatRelative = "relative" // 相对于当前目录
+	copyFormatGit      = "git"      // 相对于 Git 仓库根目录
+	copyFormatHome     = "home"     // 用 ~ 缩写主目录
+	copyFormatWindows  = "windows"  // WSL 中对应的 Windows 路径
+	copyFormatMarkdown = "markdown" // Markdown 链接
+	copyFormatContent  = "content"  // 文本文件的内容
+)
+
+var copyFormats = []string{copyFormatPath, copyFormatQuoted, copyFormatURI, copyFormatRelative, copyFormatGit, copyFormatHome, copyFormatWindows, copyFormatMarkdown, copyFormatContent}
+
+// maxCopyContent 复制文件内容时允许的最大文件大小
+const maxCopyContent = 10 << 20
+
+var (
+	copyFormat    string
+	copySeparator string
+)
+
+// shellSafePattern 不需要加引号的字符
+var shellSafePattern = regexp.MustCompile(`^[a-zA-Z0-9_@%+=:,./~-]+$`)
+
+// wslMountPattern 匹配 WSL 中挂载的 Windows 盘符，例如 /mnt/c/Users
+var wslMountPattern = regexp.MustCompile(`^/mnt/([a-zA-Z])(/.*)?$`)
+
+// resolveCopyFormat 返回使用的格式：--copy-format -> copy_format 配置 -> path
+func resolveCopyFormat() (string, error) {
+	format := copyFormat
+	if format == "" {
+		format = config.CopyFormat
+	}
+	if format == "" {
+		return copyFormatPath, nil
+	}
+	format = strings.ToLower(format)
+	if !containsString(copyFormats, format) {
+		return "", fmt.Errorf("unknown copy format: %s (available: %s)", format, strings.Join(copyFormats, ", "))
+	}
+	return format, nil
+}
+
+// resolveCopySeparator 返回多个路径之间的分隔符：--copy-separator -> copy_separator 配置 -> 换行
+// 支持 \n、\t 这类转义写法
+func resolveCopySeparator() string {
+	sep := copySeparator
+	if sep == "" {
+		sep = config.CopySeparator
+	}
+	if sep == "" {
+		return "\n"
+	}
+	if unquoted, err := strconv.Unquote(`"` + sep + `"`); err == nil {
+		return unquoted
+	}
+	return sep
+}
+
+// formatCopyTargets 按格式转换所有目标，并用分隔符连接
+func formatCopyTargets(format string, targets []templateContext) (string, error) {
+	parts := make([]string, 0, len(targets))
+	for _, t := range targets {
+		s, err := formatCopyTarget(format, t)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, resolveCopySeparator()), nil
+}
+
+// formatCopyTarget 按格式转换单个路径或 URL
+func formatCopyTarget(format string, t templateContext) (string, error) {
+	if t.URL != "" {
+		switch format {
+		case copyFormatQuoted:
+			return shellQuote(t.URL), nil
+		case copyFormatMarkdown:
+			return markdownLink(t.URL, t.URL), nil
+		case copyFormatContent:
+			return "", fmt.Errorf("cannot copy the contents of a URL: %s", t.URL)
+		default:
+			return t.URL, nil
+		}
+	}
+
+	switch format {
+	case copyFormatQuoted:
+		return shellQuote(t.Path), nil
+	case copyFormatURI:
+		return fileURI(t.Path), nil
+	case copyFormatRelative:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		return relativePath(cwd, t.Path)
+	case copyFormatGit:
+		root, ok := findGitRoot(filepath.Dir(t.Path))
+		if !ok {
+			return "", fmt.Errorf("not inside a Git repository: %s", t.Path)
+		}
+		return relativePath(root, t.Path)
+	case copyFormatHome:
+		return formatPath(t.Path), nil
+	case copyFormatWindows:
+		return windowsPath(t.Path)
+	case copyFormatMarkdown:
+		return markdownLink(filepath.Base(t.Path), fileURI(t.Path)), nil
+	case copyFormatContent:
+		return readTextFile(t.Path)
+	default:
+		return t.Path, nil
+	}
+}
+
+// relativePath 返回相对路径，统一使用 / 分隔
+func relativePath(base, path string) (string, error) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// shellQuote 给路径加上 POSIX shell 单引号，安全的路径保持不变
+func shellQuote(s string) string {
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// markdownLink 返回 Markdown 链接，转义文本中的方括号和链接中的括号
+func markdownLink(text, link string) string {
+	text = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+	link = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(link)
+	return "[" + text + "](" + link + ")"
+}
+
+// windowsPath 返回 WSL 路径对应的 Windows 路径
+// /mnt/c/Users -> C:\Users，其他路径 -> \\wsl.localhost\<发行版>\path
+func windowsPath(path string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return path, nil
+	}
+
+	p := filepath.ToSlash(path)
+	if m := wslMountPattern.FindStringSubmatch(p); m != nil {
+		return strings.ToUpper(m[1]) + ":" + strings.ReplaceAll(m[2], "/", `\`) + windowsRootSuffix(m[2]), nil
+	}
+
+	distro := os.Getenv("WSL_DISTRO_NAME")
+	if distro == "" {
+		return "", fmt.Errorf("not running under WSL (WSL_DISTRO_NAME is not set)")
+	}
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(p, "/", `\`), nil
+}
+
+// windowsRootSuffix 盘符根目录需要以 \ 结尾（C:\ 而不是 C:）
+func windowsRootSuffix(rest string) string {
+	if rest == "" {
+		return `\`
+	}
+	return ""
+}
+
+// readTextFile 读取文本文件的内容，拒绝文件夹、二进制文件和过大的文件
+func readTextFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("cannot copy the contents of a directory: %s", path)
+	}
+	if info.Size() > maxCopyContent {
+		return "", fmt.Errorf("file is too large to copy (%d bytes): %s", info.Size(), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return "", fmt.Errorf("not a text file: %s", path)
+	}
+	return string(data), nil
+}
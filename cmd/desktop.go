@@ -0,0 +1,467 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// desktopEntry freedesktop .desktop 文件中的 [Desktop Entry] 信息
+type desktopEntry struct {
+	ID        string   // 桌面 ID，例如 org.gnome.Evince.desktop
+	File      string   // .desktop 文件路径
+	Name      string   // 显示名称
+	Exec      string   // Exec= 命令行（包含 %f/%U 等字段代码）
+	Icon      string   // 图标
+	Terminal  bool     // 是否需要在终端中运行
+	MimeTypes []string // 支持的 MIME 类型
+}
+
+// mimeAssociations mimeapps.list 与 mimeinfo.cache 中的关联信息
+type mimeAssociations struct {
+	defaults map[string][]string // [Default Applications]
+	added    map[string][]string // [Added Associations]
+	removed  map[string][]string // [Removed Associations]
+	cache    map[string][]string // mimeinfo.cache 中的 [MIME Cache]
+}
+
+var (
+	desktopOnce    sync.Once
+	desktopEntries map[string]*desktopEntry
+	associations   mimeAssociations
+)
+
+// isFreedesktop 判断当前平台是否遵循 freedesktop 规范
+func isFreedesktop() bool {
+	return runtime.GOOS != "darwin" && runtime.GOOS != "windows"
+}
+
+// loadDesktopDatabase 加载所有 .desktop 文件、mimeapps.list 和 mimeinfo.cache（只加载一次）
+func loadDesktopDatabase() {
+	desktopOnce.Do(func() {
+		desktopEntries = map[string]*desktopEntry{}
+		associations = mimeAssociations{
+			defaults: map[string][]string{},
+			added:    map[string][]string{},
+			removed:  map[string][]string{},
+			cache:    map[string][]string{},
+		}
+
+		// 数据目录按优先级从高到低排列，先加载的同名桌面 ID 优先
+		for _, dir := range xdgDataDirs() {
+			appDir := filepath.Join(dir, "applications")
+			loadDesktopDir(appDir)
+			loadMimeCache(filepath.Join(appDir, "mimeinfo.cache"))
+		}
+
+		for _, file := range mimeappsListFiles() {
+			loadMimeappsList(file)
+		}
+
+		if debug {
+			fmt.Printf("🔍 Loaded %d desktop entries\n", len(desktopEntries))
+		}
+	})
+}
+
+// loadDesktopDir 递归加载目录下的 .desktop 文件
+// 子目录中的文件 ID 使用 "-" 连接，例如 kde4/okular.desktop -> kde4-okular.desktop
+func loadDesktopDir(appDir string) {
+	_ = filepath.WalkDir(appDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".desktop") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(appDir, path)
+		if err != nil {
+			return nil
+		}
+		id := strings.ReplaceAll(filepath.ToSlash(rel), "/", "-")
+		if _, exists := desktopEntries[id]; exists {
+			return nil
+		}
+
+		if entry, ok := parseDesktopFile(path); ok {
+			entry.ID = id
+			desktopEntries[id] = entry
+		}
+		return nil
+	})
+}
+
+// parseDesktopFile 解析 .desktop 文件，隐藏的或没有 Exec 的条目会被忽略
+func parseDesktopFile(path string) (*desktopEntry, bool) {
+	groups, err := parseIniFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	values := groups["Desktop Entry"]
+	if values == nil || values["Hidden"] == "true" || values["Exec"] == "" {
+		return nil, false
+	}
+	if t := values["Type"]; t != "" && t != "Application" {
+		return nil, false
+	}
+	if tryExec := values["TryExec"]; tryExec != "" && !isCommandAvailable(tryExec) {
+		if _, err := os.Stat(tryExec); err != nil {
+			return nil, false
+		}
+	}
+
+	return &desktopEntry{
+		File:      path,
+		Name:      values["Name"],
+		Exec:      values["Exec"],
+		Icon:      values["Icon"],
+		Terminal:  values["Terminal"] == "true",
+		MimeTypes: splitDesktopList(values["MimeType"]),
+	}, true
+}
+
+// parseIniFile 解析 freedesktop 风格的 ini 文件（.desktop、mimeapps.list、mimeinfo.cache）
+func parseIniFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	groups := map[string]map[string]string{}
+	var current map[string]string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := line[1 : len(line)-1]
+			if groups[name] == nil {
+				groups[name] = map[string]string{}
+			}
+			current = groups[name]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		// 忽略本地化的键，例如 Name[zh_CN]
+		if strings.Contains(key, "[") {
+			continue
+		}
+		current[key] = unescapeDesktopValue(strings.TrimSpace(value))
+	}
+	return groups, scanner.Err()
+}
+
+// unescapeDesktopValue 处理 .desktop 字符串值中的转义序列
+func unescapeDesktopValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 's':
+			b.WriteByte(' ')
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// splitDesktopList 拆分以分号分隔的列表
+func splitDesktopList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// mimeappsListFiles 返回按优先级排列的 mimeapps.list 路径
+func mimeappsListFiles() []string {
+	var files []string
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		files = append(files, filepath.Join(configHome, "mimeapps.list"))
+	}
+
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if dir != "" {
+			files = append(files, filepath.Join(dir, "mimeapps.list"))
+		}
+	}
+
+	for _, dir := range xdgDataDirs() {
+		files = append(files, filepath.Join(dir, "applications", "mimeapps.list"))
+	}
+	return files
+}
+
+// loadMimeappsList 合并一个 mimeapps.list 文件，已有的高优先级条目排在前面
+func loadMimeappsList(file string) {
+	groups, err := parseIniFile(file)
+	if err != nil {
+		return
+	}
+	mergeAssociations(associations.defaults, groups["Default Applications"])
+	mergeAssociations(associations.added, groups["Added Associations"])
+	mergeAssociations(associations.removed, groups["Removed Associations"])
+}
+
+// loadMimeCache 合并一个 mimeinfo.cache 文件
+func loadMimeCache(file string) {
+	groups, err := parseIniFile(file)
+	if err != nil {
+		return
+	}
+	mergeAssociations(associations.cache, groups["MIME Cache"])
+}
+
+// mergeAssociations 将 "mime=a.desktop;b.desktop;" 形式的条目追加到目标映射
+func mergeAssociations(target map[string][]string, group map[string]string) {
+	for mimeType, value := range group {
+		for _, id := range splitDesktopList(value) {
+			if !containsString(target[mimeType], id) {
+				target[mimeType] = append(target[mimeType], id)
+			}
+		}
+	}
+}
+
+// desktopEntriesForMIME 返回与 MIME 类型关联的桌面条目，默认应用排在最前
+func desktopEntriesForMIME(mimeType string) []*desktopEntry {
+	loadDesktopDatabase()
+
+	var ids []string
+	for _, source := range [][]string{
+		associations.defaults[mimeType],
+		associations.added[mimeType],
+		associations.cache[mimeType],
+	} {
+		for _, id := range source {
+			if !containsString(ids, id) && !containsString(associations.removed[mimeType], id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	var entries []*desktopEntry
+	for _, id := range ids {
+		if entry, exists := desktopEntries[id]; exists {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// findDesktopEntry 根据应用名称或桌面 ID 查找桌面条目
+// 匹配顺序：桌面 ID（可省略 .desktop）-> 显示名称 -> Exec 中的程序名。
+// 有多个候选时，优先选择与 mimeType 关联的条目
+func findDesktopEntry(app string, mimeType string) (*desktopEntry, bool) {
+	if !isFreedesktop() {
+		return nil, false
+	}
+	loadDesktopDatabase()
+
+	id := app
+	if !strings.HasSuffix(id, ".desktop") {
+		id += ".desktop"
+	}
+	if entry, exists := desktopEntries[id]; exists {
+		return entry, true
+	}
+
+	var candidates []*desktopEntry
+	for _, entry := range desktopEntries {
+		if strings.EqualFold(entry.Name, app) || strings.EqualFold(filepath.Base(commandProgram(entry.Exec)), app) {
+			candidates = append(candidates, entry)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	if mimeType != "" {
+		for _, associated := range desktopEntriesForMIME(mimeType) {
+			for _, candidate := range candidates {
+				if candidate == associated {
+					return candidate, true
+				}
+			}
+		}
+	}
+	return candidates[0], true
+}
+
+// desktopCommands 展开 Exec= 中的字段代码，返回需要执行的命令
+// %f/%u 只接受单个文件，多个文件时会启动多个实例；%F/%U 接受文件列表
+func desktopCommands(entry *desktopEntry, files []string) ([]appLaunch, error) {
+	fields, err := splitCommandLine(entry.Exec)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("⚠️ empty Exec in %s", entry.File)
+	}
+
+	single := false
+	hasCode := false
+	for _, field := range fields {
+		if strings.Contains(field, "%f") || strings.Contains(field, "%u") {
+			single = true
+		}
+		for _, code := range []string{"%f", "%F", "%u", "%U"} {
+			if strings.Contains(field, code) {
+				hasCode = true
+			}
+		}
+	}
+
+	// 没有字段代码时，按惯例把文件追加到末尾
+	if !hasCode {
+		fields = append(fields, "%F")
+	}
+
+	groups := [][]string{files}
+	if single {
+		groups = nil
+		for _, file := range files {
+			groups = append(groups, []string{file})
+		}
+	}
+
+	var launches []appLaunch
+	for _, group := range groups {
+		var argv []string
+		for _, field := range fields {
+			argv = append(argv, expandFieldCode(field, entry, group)...)
+		}
+		if len(argv) > 0 {
+			launches = append(launches, appLaunch{Command: Command{Name: argv[0], Args: argv[1:]}, Paths: group})
+		}
+	}
+	return launches, nil
+}
+
+// expandFieldCode 展开单个参数中的字段代码
+func expandFieldCode(field string, entry *desktopEntry, files []string) []string {
+	switch field {
+	case "%F":
+		args := make([]string, 0, len(files))
+		for _, file := range files {
+			args = append(args, desktopFileArg(file))
+		}
+		return args
+	case "%U":
+		uris := make([]string, 0, len(files))
+		for _, file := range files {
+			uris = append(uris, desktopURIArg(file))
+		}
+		return uris
+	case "%i":
+		if entry.Icon == "" {
+			return nil
+		}
+		return []string{"--icon", entry.Icon}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '%' || i+1 >= len(field) {
+			b.WriteByte(field[i])
+			continue
+		}
+		i++
+		switch field[i] {
+		case 'f':
+			if len(files) > 0 {
+				b.WriteString(desktopFileArg(files[0]))
+			}
+		case 'u':
+			if len(files) > 0 {
+				b.WriteString(desktopURIArg(files[0]))
+			}
+		case 'c':
+			b.WriteString(entry.Name)
+		case 'k':
+			b.WriteString(entry.File)
+		case '%':
+			b.WriteByte('%')
+		default:
+			// 已废弃的字段代码（%d %D %n %N %v %m）直接删除
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+	return []string{b.String()}
+}
+
+// desktopFileArg 返回 %f/%F 使用的参数
+// file:// URI 转为本地路径，其他 URL 原样传递，不当作本地路径处理
+func desktopFileArg(target string) string {
+	switch uriScheme(target) {
+	case "":
+		return target
+	case "file":
+		if path, err := fileURIToPath(target); err == nil {
+			return path
+		}
+	}
+	return target
+}
+
+// desktopURIArg 返回 %u/%U 使用的参数：URL 原样传递，本地路径转为 file:// URI
+func desktopURIArg(target string) string {
+	if uriScheme(target) != "" {
+		return target
+	}
+	return fileURI(target)
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDesktopCommands(t *testing.T) {
+	entry := &desktopEntry{Name: "Text Editor", File: "/usr/share/applications/editor.desktop", Icon: "editor"}
+	files := []string{"/tmp/a b.txt", "/tmp/c.txt"}
+
+	tests := []struct {
+		name  string
+		exec  string
+		files []string
+		want  [][]string
+	}{
+		{"%F gets all files", "editor %F", files, [][]string{{"editor", "/tmp/a b.txt", "/tmp/c.txt"}}},
+		{"%f runs once per file", "editor %f", files, [][]string{{"editor", "/tmp/a b.txt"}, {"editor", "/tmp/c.txt"}}},
+		{"%U gets URIs", "browser %U", files, [][]string{{"browser", "file:///tmp/a%20b.txt", "file:///tmp/c.txt"}}},
+		{"%u runs once per file", "browser --new %u", files[1:], [][]string{{"browser", "--new", "file:///tmp/c.txt"}}},
+		{"no field code appends files", "editor --wait", files, [][]string{{"editor", "--wait", "/tmp/a b.txt", "/tmp/c.txt"}}},
+		{"%c and %k", "editor --class=%c --desktop=%k %F", files[:1], [][]string{{"editor", "--class=Text Editor", "--desktop=/usr/share/applications/editor.desktop", "/tmp/a b.txt"}}},
+		{"%i expands to --icon", "editor %i %F", files[:1], [][]string{{"editor", "--icon", "editor", "/tmp/a b.txt"}}},
+		{"deprecated codes removed", "editor %d %m %F", files[:1], [][]string{{"editor", "/tmp/a b.txt"}}},
+		{"literal percent", "editor --zoom=100%% %F", files[:1], [][]string{{"editor", "--zoom=100%", "/tmp/a b.txt"}}},
+		{"quoted program", `"/opt/My Editor/bin/editor" %F`, files[:1], [][]string{{"/opt/My Editor/bin/editor", "/tmp/a b.txt"}}},
+		{"file inside field", "editor --file=%f", files[:1], [][]string{{"editor", "--file=/tmp/a b.txt"}}},
+		{"%u keeps URLs", "browser %u", []string{"https://go.dev/doc"}, [][]string{{"browser", "https://go.dev/doc"}}},
+		{"%U keeps URLs", "browser %U", []string{"https://go.dev", "/tmp/c.txt"}, [][]string{{"browser", "https://go.dev", "file:///tmp/c.txt"}}},
+		{"%f keeps URLs", "player %f", []string{"https://example.com/a.mp4"}, [][]string{{"player", "https://example.com/a.mp4"}}},
+		{"%F decodes file URIs", "editor %F", []string{"file:///tmp/a%20b.txt"}, [][]string{{"editor", "/tmp/a b.txt"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := *entry
+			e.Exec = tt.exec
+			launches, err := desktopCommands(&e, tt.files)
+			if err != nil {
+				t.Fatalf("desktopCommands(%q): %v", tt.exec, err)
+			}
+			var got [][]string
+			for _, launch := range launches {
+				got = append(got, launch.Command.Argv())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("desktopCommands(%q) = %q, want %q", tt.exec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesktopCommandsInvalidExec(t *testing.T) {
+	for _, exec := range []string{"", `editor "%F`} {
+		if _, err := desktopCommands(&desktopEntry{Exec: exec}, []string{"/tmp/a"}); err == nil {
+			t.Errorf("desktopCommands(%q): expected an error", exec)
+		}
+	}
+}
+
+func TestParseDesktopFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "editor.desktop")
+	content := `[Desktop Entry]
+Type=Application
+Name=Editor
+Name[zh_CN]=编辑器
+Exec=editor\s--title "My\sFiles" %U
+Icon=editor
+Terminal=true
+MimeType=text/plain;text/markdown;
+
+[Desktop Action new-window]
+Exec=editor --new-window
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := parseDesktopFile(path)
+	if !ok {
+		t.Fatal("parseDesktopFile: entry was ignored")
+	}
+	want := &desktopEntry{
+		File:      path,
+		Name:      "Editor",
+		Exec:      `editor --title "My Files" %U`,
+		Icon:      "editor",
+		Terminal:  true,
+		MimeTypes: []string{"text/plain", "text/markdown"},
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("parseDesktopFile = %+v, want %+v", entry, want)
+	}
+
+	launches, err := desktopCommands(entry, []string{"/tmp/a.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := launches[0].Command.Argv(), []string{"editor", "--title", "My Files", "file:///tmp/a.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("argv = %q, want %q", got, want)
+	}
+}
+
+func TestParseDesktopFileIgnored(t *testing.T) {
+	tests := map[string]string{
+		"hidden":  "[Desktop Entry]\nExec=editor\nHidden=true\n",
+		"no exec": "[Desktop Entry]\nName=Editor\n",
+		"link":    "[Desktop Entry]\nType=Link\nExec=editor\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "entry.desktop")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := parseDesktopFile(path); ok {
+				t.Errorf("parseDesktopFile(%q): expected the entry to be ignored", content)
+			}
+		})
+	}
+}
+
+// useDesktopEntries 用给定的 .desktop 条目代替系统中的应用数据库
+func useDesktopEntries(t *testing.T, entries ...*desktopEntry) {
+	t.Helper()
+	savedEntries, savedAssociations := desktopEntries, associations
+	desktopOnce = sync.Once{}
+	desktopOnce.Do(func() {
+		desktopEntries = map[string]*desktopEntry{}
+		for _, entry := range entries {
+			desktopEntries[entry.ID] = entry
+		}
+		associations = mimeAssociations{}
+	})
+	t.Cleanup(func() {
+		desktopEntries, associations = savedEntries, savedAssociations
+		desktopOnce = sync.Once{}
+	})
+}
+
+func TestResolveAppCommandsURLDesktopEntry(t *testing.T) {
+	useFakeLauncher(t, "xdg-open")
+	useDesktopEntries(t, &desktopEntry{ID: "firefox.desktop", Name: "Firefox", Exec: "firefox %u"})
+
+	targets := []templateContext{{Path: "https://go.dev", URL: "https://go.dev"}}
+	launches, err := resolveAppCommands("firefox", targets)
+	if err != nil {
+		t.Fatalf("resolveAppCommands: %v", err)
+	}
+	if len(launches) != 1 {
+		t.Fatalf("launches = %+v, want one launch", launches)
+	}
+	if got, want := launches[0].Command.Argv(), []string{"firefox", "https://go.dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("argv = %q, want %q", got, want)
+	}
+}
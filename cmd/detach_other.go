@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package cmd
+
+import "syscall"
+
+// detachedProcAttr 当前平台不支持脱离会话，子进程仍然不会连接到终端
+func detachedProcAttr() *syscall.SysProcAttr {
+	return nil
+}
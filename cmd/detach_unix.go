@@ -0,0 +1,10 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cmd
+
+import "syscall"
+
+// detachedProcAttr 让子进程运行在自己的会话中，关闭终端时不会收到 SIGHUP
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package cmd
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// detachedProcAttr 让子进程使用独立的进程组且不继承控制台
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+	}
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var explainJSON bool
+
+// explainStep 解析过程中的一步
+type explainStep struct {
+	Step   string `json:"step"`
+	Detail string `json:"detail"`
+}
+
+// explanation 一个参数的完整解析过程
+type explanation struct {
+	Input     string        `json:"input"`
+	Path      string        `json:"path,omitempty"`
+	URL       string        `json:"url,omitempty"`
+	Line      int           `json:"line,omitempty"`
+	Col       int           `json:"col,omitempty"`
+	Kind      string        `json:"kind"` // file, directory, url
+	Extension string        `json:"extension,omitempty"`
+	MIME      string        `json:"mime,omitempty"`
+	ConfigKey string        `json:"config_key,omitempty"` // 命中的规则或 file_type_apps / scheme_handlers 键
+	App       string        `json:"app,omitempty"`
+	Manager   string        `json:"manager,omitempty"`
+	Opener    string        `json:"opener,omitempty"`
+	Commands  [][]string    `json:"commands"`
+	Error     string        `json:"error,omitempty"`
+	Steps     []explainStep `json:"steps"`
+}
+
+func (e *explanation) step(name, format string, args ...interface{}) {
+	e.Steps = append(e.Steps, explainStep{Step: name, Detail: fmt.Sprintf(format, args...)})
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [path...]",
+	Short: "explain how a path would be opened",
+	Long: `Walk through every decision made when opening a path without running
+anything: path normalization, extension and MIME detection, the matching
+config key, manager override, default manager, opener fallback and the final
+command line.
+
+Examples:
+  of explain main.go
+  of explain -m code src/
+  of explain --json notes.md:12 | jq '.[0].commands'`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeBookmarkPaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		// explain 不执行任何操作：不启动程序、不写入配置、历史记录和历史版本文件
+		dryRun = true
+		loadConfig()
+
+		// -m 与 default_manager 的来源需要在 useDefaultManager 修改 manager 之前记录
+		managerSource := ""
+		switch {
+		case manager != "":
+			managerSource = "-m flag"
+		case config.DefaultManager != "":
+			managerSource = "default_manager"
+		}
+
+		explanations := make([]*explanation, 0, len(args))
+		failed := false
+		for _, arg := range args {
+			for _, e := range explainArg(arg, managerSource) {
+				explanations = append(explanations, e)
+				failed = failed || e.Error != ""
+			}
+		}
+
+		if explainJSON {
+			data, err := json.MarshalIndent(explanations, "", "  ")
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			for i, e := range explanations {
+				if i > 0 {
+					fmt.Println()
+				}
+				printExplanation(e)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// explainArg 解析一个参数（通配符可能展开为多个路径）
+func explainArg(arg, managerSource string) []*explanation {
+	targets, failures := resolvePathArgs([]string{arg})
+	var explanations []*explanation
+	for _, err := range failures {
+		e := &explanation{Input: arg, Kind: "unknown", Error: err.Error()}
+		e.step("input", "%s", arg)
+		e.step("normalize", "failed: %v", err)
+		explanations = append(explanations, e)
+	}
+
+	for _, t := range targets {
+		e := &explanation{Input: arg, Line: t.Line, Col: t.Col}
+		e.step("input", "%s", arg)
+		explainTarget(e, arg, t, managerSource)
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
+
+// explainTarget 按实际打开时的顺序记录每一步的决定
+func explainTarget(e *explanation, arg string, t templateContext, managerSource string) {
+	// 路径规范化
+	switch {
+	case t.URL != "":
+		e.URL = t.URL
+		e.Kind = "url"
+		e.step("normalize", "recognized as URL (scheme %s)", uriScheme(t.URL))
+	default:
+		e.Path = t.Path
+		var notes []string
+		if uriScheme(arg) == "file" {
+			notes = append(notes, "decoded file:// URI")
+		}
+		if expandBookmark(arg) != arg {
+			notes = append(notes, "expanded bookmark")
+		}
+		if hasGlobMeta(arg) && !isPathValid(arg) {
+			notes = append(notes, "matched glob pattern")
+		}
+		if source := revSourcePath(t.Path); source != t.Path {
+			notes = append(notes, "revision of "+formatPath(source)+", not written to disk")
+		}
+		if t.Line > 0 {
+			notes = append(notes, "parsed line/column suffix")
+		}
+		detail := formatLocation(t)
+		if len(notes) > 0 {
+			detail += " (" + strings.Join(notes, ", ") + ")"
+		}
+		e.step("normalize", "%s", detail)
+
+		e.Kind = "directory"
+		if isFile(t.Path) {
+			e.Kind = "file"
+		}
+		e.step("type", "%s", e.Kind)
+	}
+
+	// 文件类型匹配
+	usesManager := e.Kind == "directory"
+	switch e.Kind {
+	case "file":
+		e.Extension = getFileExtension(t.Path)
+		e.MIME = detectMIME(t.Path)
+		e.step("extension", "%s", valueOrNone(e.Extension))
+		e.step("mime", "%s", e.MIME)
+
+		if i, rule := matchRule(t.Path); i >= 0 {
+			e.ConfigKey, e.App = rule.label(i), rule.App
+			e.step("rules", "matched %s: %s -> %s", e.ConfigKey, rule.conditions(), e.App)
+		} else {
+			if len(config.Rules) > 0 {
+				e.step("rules", "no rule matched (%d rules)", len(config.Rules))
+			}
+			e.ConfigKey, e.App = matchFileTypeApp(t.Path)
+			if e.App != "" {
+				e.step("file_type_apps", "matched key %q -> %s", formatFileTypeKey(e.ConfigKey), e.App)
+			} else {
+				e.step("file_type_apps", "no matching key, using file manager")
+			}
+		}
+		if e.App != "" {
+			detail, found := describeAppResolution(e.App, t)
+			e.step("app", "%s", detail)
+			usesManager = !found
+		} else {
+			usesManager = true
+		}
+	case "directory":
+		switch {
+		case plainDirectories:
+			e.step("directory_rules", "skipped (--plain), using file manager")
+		case explicitManager():
+			e.step("directory_rules", "skipped (-m), using file manager")
+		case len(config.DirectoryRules) == 0:
+		default:
+			if i, rule := matchDirectoryRule(t.Path); i >= 0 {
+				e.ConfigKey, e.App = "directory "+rule.label(i), rule.App
+				e.step("directory_rules", "matched %s: %s -> %s", rule.label(i), rule.conditions(), e.App)
+				detail, found := describeAppResolution(e.App, t)
+				e.step("app", "%s", detail)
+				usesManager = !found
+			} else {
+				e.step("directory_rules", "no rule matched (%d rules, markers searched %d levels up), using file manager", len(config.DirectoryRules), markerDepth())
+			}
+		}
+	case "url":
+		scheme := uriScheme(t.URL)
+		if handler := config.SchemeHandlers[scheme]; handler != "" {
+			e.ConfigKey, e.App = scheme, handler
+			e.step("scheme_handlers", "matched key %q -> %s", scheme, handler)
+			detail, _ := describeAppResolution(handler, t)
+			e.step("app", "%s", detail)
+		} else {
+			e.step("scheme_handlers", "no handler for %q, using platform opener", scheme)
+		}
+	}
+
+	// 文件管理器（只有文件夹和没有配置应用的文件会用到）
+	useDefaultManager()
+	e.Manager = manager
+	switch {
+	case !usesManager:
+		e.step("manager", "not used")
+	case manager == "":
+		e.step("manager", "none, using platform opener")
+	default:
+		mode := ""
+		if isTerminalManager(manager) {
+			mode = ", runs in terminal"
+		}
+		if customCmd, exists := getCustomManager(manager); exists {
+			e.step("manager", "%s from %s (custom manager: %s%s)", manager, managerSource, customCmd, mode)
+		} else {
+			e.step("manager", "%s from %s (run directly, platform opener on failure%s)", manager, managerSource, mode)
+		}
+	}
+
+	// 平台默认打开方式
+	if opener, err := selectOpenerFor(canOpenPath); err != nil {
+		e.step("opener", "%v", err)
+	} else {
+		e.Opener = opener.Name()
+		source := "auto-detected"
+		if config.Opener != "" {
+			source = "from config"
+		}
+		e.step("opener", "%s (%s)", opener.Name(), source)
+	}
+
+	// 没有图形界面时的回退策略
+	if reason := headlessReason(); reason != "" {
+		e.step("headless", "%s, fallback policy: %s", reason, headlessPolicy())
+	}
+
+	// 用记录模式的启动器走一遍真实的打开流程，得到最终的命令
+	recorder := &dryRunLauncher{Record: true}
+	restore := SetLauncher(recorder)
+	result := openPaths([]templateContext{t})[0]
+	restore()
+
+	e.Commands = make([][]string, 0, len(recorder.Calls))
+	for _, c := range recorder.Calls {
+		e.Commands = append(e.Commands, c.Argv())
+		e.step("command", "%s", c)
+	}
+	for _, p := range recorder.Prints {
+		if p.TTY {
+			e.step("output", "write to the terminal: %s", p)
+		} else {
+			e.step("output", "print: %s", p)
+		}
+	}
+	if result.Err != nil {
+		e.Error = result.Err.Error()
+		e.step("error", "%v", result.Err)
+	}
+}
+
+// describeAppResolution 说明应用名称会被如何解析（与 resolveAppCommands 的顺序一致）
+// 应用无法解析、会退回到文件管理器时返回 false
+func describeAppResolution(app string, t templateContext) (string, bool) {
+	if customCmd, exists := getCustomManager(app); exists {
+		return fmt.Sprintf("custom manager %s -> %s", app, customCmd), true
+	}
+	if isCommandTemplate(app) {
+		return "command template", true
+	}
+	if isFreedesktop() {
+		mimeType := "x-scheme-handler/" + uriScheme(t.URL)
+		if t.URL == "" {
+			mimeType = detectMIME(t.Path)
+		}
+		if entry, ok := findDesktopEntry(app, mimeType); ok {
+			return fmt.Sprintf("desktop entry %s (%s)", entry.ID, entry.File), true
+		}
+	}
+	if opener, err := selectOpenerFor(canOpenWithApp); err == nil {
+		if _, ok := opener.OpenWithCommand(app, []string{t.Path}); ok {
+			return fmt.Sprintf("application opened via %s", opener.Name()), true
+		}
+	}
+	if p, err := launcher.LookPath(app); err == nil {
+		return fmt.Sprintf("program in PATH (%s)", p), true
+	}
+	return "not found, falling back to file manager", false
+}
+
+// printExplanation 以文本形式输出解析过程
+func printExplanation(e *explanation) {
+	fmt.Printf("🔎 %s\n", e.Input)
+	for i, s := range e.Steps {
+		fmt.Printf("  %2d. %-16s %s\n", i+1, s.Step, s.Detail)
+	}
+}
+
+// valueOrNone 空字符串显示为 (none)
+func valueOrNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&explainJSON, "json", false, "output JSON")
+	explainCmd.Flags().StringVarP(&manager, "manager", "m", "", "specify file manager to use")
+	explainCmd.Flags().BoolVar(&plainDirectories, "plain", false, "always open folders in the file manager, ignoring directory_rules")
+	rootCmd.AddCommand(explainCmd)
+}
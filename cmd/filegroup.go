@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// 文件类型组是一组扩展名的名称，file_type_apps 和 rules 的 ext 中用 group:<name> 引用：
+//
+//	file_groups:
+//	  notebook: [ipynb]
+//	  cad: [step, stl, dxf]
+//	file_type_apps:
+//	  group:audio: IINA
+//
+// 映射只保存组名，修改组的扩展名会立即作用于所有引用它的映射。
+// file_groups 中的同名组覆盖内置组，空列表表示删除内置组。
+
+// fileGroupPrefix file_type_apps 中引用文件类型组的键前缀
+const fileGroupPrefix = "group:"
+
+// defaultFileGroups 内置的文件类型组
+var defaultFileGroups = map[string][]string{
+	"audio":        {"mp3", "wav", "flac", "aac", "ogg", "m4a", "wma"},
+	"video":        {"mp4", "avi", "mkv", "mov", "wmv", "flv", "webm", "m4v", "3gp"},
+	"image":        {"jpg", "jpeg", "png", "gif", "bmp", "svg", "tiff", "webp"},
+	"document":     {"pdf", "doc", "docx", "txt", "md", "rtf"},
+	"code":         {"py", "js", "ts", "go", "java", "cpp", "c", "h", "html", "css", "json", "xml", "yaml", "yml"},
+	"archive":      {"zip", "rar", "7z", "tar", "gz", "bz2"},
+	"spreadsheet":  {"xls", "xlsx", "csv"},
+	"presentation": {"ppt", "pptx"},
+}
+
+// fileGroupNamePattern 组名只允许小写字母、数字、- 和 _（配置键中不能有点）
+var fileGroupNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "manage file type groups",
+	Long: `Manage file type groups (named lists of extensions).
+
+Groups are referenced from file_type_apps as group:<name>, so changing a group
+affects every mapping that uses it:
+  of config group add notebook ipynb
+  of config add-filegroup notebook jupyter-lab    # maps group:notebook
+  of config group add code vue                    # .vue now opens with the code mapping`,
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:               "add [group] [extension...]",
+	Short:             "create a group or add extensions to it",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeFileGroupNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := normalizeFileGroupName(args[0])
+		if err := validateFileGroupName(name); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		groups := fileGroups()
+		existing, exists := groups[name]
+		extensions := append([]string{}, existing...)
+		var added []string
+		for _, ext := range normalizeExtensions(args[1:]) {
+			if !containsString(extensions, ext) {
+				extensions = append(extensions, ext)
+				added = append(added, ext)
+			}
+		}
+		if len(added) == 0 {
+			fmt.Printf("✅ Group %s already contains: %s\n", name, strings.Join(normalizeExtensions(args[1:]), ", "))
+			return
+		}
+
+		saveFileGroup(name, extensions)
+
+		if exists {
+			fmt.Printf("✅ Added to group %s: %s\n", name, strings.Join(added, ", "))
+		} else {
+			fmt.Printf("✅ Created group %s: %s\n", name, strings.Join(added, ", "))
+		}
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:               "rm [group] [extension...]",
+	Aliases:           []string{"remove"},
+	Short:             "remove extensions from a group, or the whole group",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeFileGroupNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := normalizeFileGroupName(args[0])
+		extensions, exists := fileGroups()[name]
+		if !exists {
+			fmt.Printf("❌ File group %s not found (see: of config group ls)\n", name)
+			os.Exit(1)
+		}
+
+		// 只指定组名时删除整个组
+		if len(args) == 1 {
+			saveFileGroup(name, nil)
+			fmt.Printf("✅ Removed group: %s\n", name)
+			if app, mapped := config.FileTypeApps[fileGroupKey(name)]; mapped {
+				fmt.Printf("⚠️ Mapping %s -> %s no longer matches any file (see: of config remove-filetype %s)\n", fileGroupKey(name), app, fileGroupKey(name))
+			}
+			return
+		}
+
+		var kept, removed []string
+		remove := normalizeExtensions(args[1:])
+		for _, ext := range extensions {
+			if containsString(remove, ext) {
+				removed = append(removed, ext)
+			} else {
+				kept = append(kept, ext)
+			}
+		}
+		if len(removed) == 0 {
+			fmt.Printf("❌ Group %s does not contain: %s\n", name, strings.Join(remove, ", "))
+			os.Exit(1)
+		}
+
+		saveFileGroup(name, kept)
+		fmt.Printf("✅ Removed from group %s: %s\n", name, strings.Join(removed, ", "))
+	},
+}
+
+var groupListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "list file type groups",
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		groups := fileGroups()
+		if len(groups) == 0 {
+			fmt.Println("📂 No file groups found")
+			return
+		}
+
+		fmt.Println("📂 File groups:")
+		for _, name := range fileGroupNames() {
+			mapping := ""
+			if app, mapped := config.FileTypeApps[fileGroupKey(name)]; mapped {
+				mapping = " -> " + app
+			}
+			fmt.Printf("  %s (%s): %s%s\n", name, fileGroupSource(name), strings.Join(groups[name], ", "), mapping)
+		}
+	},
+}
+
+var groupShowCmd = &cobra.Command{
+	Use:               "show [group]",
+	Short:             "show a group's extensions and the mappings that use it",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFileGroupNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		name := normalizeFileGroupName(args[0])
+		extensions, exists := fileGroups()[name]
+		if !exists {
+			fmt.Printf("❌ File group %s not found (see: of config group ls)\n", name)
+			os.Exit(1)
+		}
+
+		fmt.Printf("📂 Group: %s (%s)\n", name, fileGroupSource(name))
+		fmt.Printf("📄 Extensions: %s\n", strings.Join(extensions, ", "))
+
+		key := fileGroupKey(name)
+		if app, mapped := config.FileTypeApps[key]; mapped {
+			fmt.Printf("🔗 Mapping: %s -> %s\n", key, app)
+			// 扩展名单独的映射优先于组的映射
+			for _, ext := range extensions {
+				if other, exists := config.FileTypeApps[ext]; exists {
+					fmt.Printf("  ⚠️ .%s uses %s (file_type_apps.%s takes precedence)\n", ext, other, ext)
+				}
+			}
+		} else {
+			fmt.Printf("🔗 Mapping: none (add one with: of config add-filegroup %s [app])\n", name)
+		}
+
+		for i, rule := range config.Rules {
+			if containsString(rule.Ext, key) {
+				fmt.Printf("📏 Used by %s -> %s\n", rule.label(i), rule.App)
+			}
+		}
+	},
+}
+
+// fileGroups 返回内置组与 file_groups 合并后的文件类型组
+func fileGroups() map[string][]string {
+	groups := make(map[string][]string, len(defaultFileGroups)+len(config.FileGroups))
+	for name, extensions := range defaultFileGroups {
+		groups[name] = extensions
+	}
+	for name, extensions := range config.FileGroups {
+		name = normalizeFileGroupName(name)
+		if len(extensions) == 0 {
+			delete(groups, name) // 空列表删除内置组
+			continue
+		}
+		groups[name] = normalizeExtensions(extensions)
+	}
+	return groups
+}
+
+// fileGroupNames 返回排序后的组名
+func fileGroupNames() []string {
+	groups := fileGroups()
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fileGroupSource 返回组的来源：built-in、custom 或 modified（覆盖了内置组）
+func fileGroupSource(name string) string {
+	_, builtin := defaultFileGroups[name]
+	_, configured := config.FileGroups[name]
+	switch {
+	case builtin && configured:
+		return "modified"
+	case builtin:
+		return "built-in"
+	default:
+		return "custom"
+	}
+}
+
+// saveFileGroup 保存组的扩展名，extensions 为空时删除组
+func saveFileGroup(name string, extensions []string) {
+	if config.FileGroups == nil {
+		config.FileGroups = make(map[string][]string)
+	}
+
+	_, builtin := defaultFileGroups[name]
+	switch {
+	case len(extensions) == 0 && builtin:
+		config.FileGroups[name] = []string{} // 删除内置组需要保留空列表
+	case len(extensions) == 0:
+		delete(config.FileGroups, name)
+	default:
+		config.FileGroups[name] = extensions
+	}
+
+	viper.Set("file_groups", config.FileGroups)
+	if err := writeConfig(); err != nil {
+		fmt.Printf("❌ Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fileGroupKey 返回引用组的 file_type_apps 键
+func fileGroupKey(name string) string {
+	return fileGroupPrefix + name
+}
+
+// fileGroupName 从 group:<name> 中取出组名
+func fileGroupName(key string) (string, bool) {
+	if !strings.HasPrefix(key, fileGroupPrefix) {
+		return "", false
+	}
+	return normalizeFileGroupName(strings.TrimPrefix(key, fileGroupPrefix)), true
+}
+
+// isFileGroupKey 判断 file_type_apps 的键是否引用文件类型组
+func isFileGroupKey(key string) bool {
+	return strings.HasPrefix(key, fileGroupPrefix)
+}
+
+// matchFileGroupApp 返回包含扩展名、并且在 file_type_apps 中有映射的组，按组名顺序第一个生效
+func matchFileGroupApp(ext string) (string, string) {
+	groups := fileGroups()
+	for _, name := range fileGroupNames() {
+		key := fileGroupKey(name)
+		app, mapped := config.FileTypeApps[key]
+		if mapped && containsString(groups[name], ext) {
+			return key, app
+		}
+	}
+	return "", ""
+}
+
+// collapseFileGroupEntries 删除被组映射取代的扩展名映射，返回删除的扩展名和仍然优先于组映射的扩展名
+// 旧版本的 add-filegroup 为组中的每个扩展名单独保存映射，这些映射优先于 group:<name>，
+// 因此与新映射相同的条目、以及整组都映射到同一个应用（旧版本写入）的条目都会删除
+func collapseFileGroupEntries(extensions []string, app string) (removed, shadowed []string) {
+	legacyApp, legacy := "", len(extensions) > 0
+	for _, ext := range extensions {
+		other, exists := config.FileTypeApps[ext]
+		if !exists || (legacyApp != "" && other != legacyApp) {
+			legacy = false
+			break
+		}
+		legacyApp = other
+	}
+
+	for _, ext := range extensions {
+		other, exists := config.FileTypeApps[ext]
+		if !exists {
+			continue
+		}
+		if legacy || other == app {
+			delete(config.FileTypeApps, ext)
+			removed = append(removed, ext)
+		} else {
+			shadowed = append(shadowed, ext)
+		}
+	}
+	return removed, shadowed
+}
+
+// extensionMatches 判断扩展名是否匹配 ext 条件中的一项，group:<name> 匹配组中的任意扩展名
+func extensionMatches(pattern, ext string) bool {
+	if name, ok := fileGroupName(pattern); ok {
+		return containsString(fileGroups()[name], ext)
+	}
+	return strings.EqualFold(strings.TrimPrefix(pattern, "."), ext)
+}
+
+// normalizeFileGroupName 组名统一为小写
+func normalizeFileGroupName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// validateFileGroupName 检查组名是否有效
+func validateFileGroupName(name string) error {
+	if !fileGroupNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid group name: %q (use letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// normalizeExtensions 扩展名统一为小写、去掉开头的点并去重，也接受逗号分隔的列表
+func normalizeExtensions(values []string) []string {
+	var extensions []string
+	for _, value := range values {
+		for _, ext := range strings.Split(value, ",") {
+			ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+			if ext != "" && !containsString(extensions, ext) {
+				extensions = append(extensions, ext)
+			}
+		}
+	}
+	return extensions
+}
+
+// completeFileGroupNames 补全组名
+func completeFileGroupNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	loadConfig()
+	return fileGroupNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	groupCmd.AddCommand(groupAddCmd)
+	groupCmd.AddCommand(groupRemoveCmd)
+	groupCmd.AddCommand(groupListCmd)
+	groupCmd.AddCommand(groupShowCmd)
+	configCmd.AddCommand(groupCmd)
+}
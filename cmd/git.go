@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 直接读取 .git 目录中的文件，不调用 git 命令，也不访问网络
+
+// gitRepo 一个 Git 仓库
+type gitRepo struct {
+	Root      string // 工作区根目录
+	Dir       string // Git 目录（.git，worktree 中为 .git/worktrees/<name>）
+	CommonDir string // 共享的 Git 目录，保存 config、refs 和 objects
+
+	packs []*packIndex // 已加载的打包索引，首次查找打包对象时读取
+}
+
+// 已知代码托管平台
+const (
+	forgeGitHub    = "github"
+	forgeGitLab    = "gitlab"
+	forgeGitea     = "gitea"
+	forgeBitbucket = "bitbucket"
+)
+
+var gitForges = []string{forgeGitHub, forgeGitLab, forgeGitea, forgeBitbucket}
+
+// scpRemotePattern 匹配 scp 形式的远程地址，例如 git@github.com:owner/repo.git
+var scpRemotePattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// findGitRoot 从 dir 向上查找 Git 仓库的根目录（包含 .git 的目录）
+// .git 可以是目录，也可以是 worktree/submodule 使用的文件
+func findGitRoot(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// openGitRepo 打开包含 path 的仓库，返回的错误不包含路径，由调用方补充
+func openGitRepo(path string) (*gitRepo, error) {
+	dir := path
+	if !isDirectory(path) {
+		dir = filepath.Dir(path)
+	}
+	root, ok := findGitRoot(dir)
+	if !ok {
+		return nil, fmt.Errorf("not inside a Git repository")
+	}
+
+	repo := &gitRepo{Root: root, Dir: filepath.Join(root, ".git")}
+	if isFile(repo.Dir) {
+		// worktree 和 submodule：.git 文件内容为 "gitdir: <路径>"
+		data, err := os.ReadFile(repo.Dir)
+		if err != nil {
+			return nil, err
+		}
+		gitdir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+		if !filepath.IsAbs(gitdir) {
+			gitdir = filepath.Join(root, gitdir)
+		}
+		repo.Dir = filepath.Clean(gitdir)
+	}
+
+	repo.CommonDir = repo.Dir
+	if data, err := os.ReadFile(filepath.Join(repo.Dir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(repo.Dir, common)
+		}
+		repo.CommonDir = filepath.Clean(common)
+	}
+	return repo, nil
+}
+
+// isDirectory 判断路径是否为已存在的目录
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// head 返回当前分支名（分离 HEAD 时为空）和提交哈希
+func (r *gitRepo) head() (string, string, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, "HEAD"))
+	if err != nil {
+		return "", "", fmt.Errorf("cannot read HEAD: %v", err)
+	}
+
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref:") {
+		return "", head, nil
+	}
+	ref := strings.TrimSpace(strings.TrimPrefix(head, "ref:"))
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	commit, _ := r.resolveRef(ref) // 新仓库还没有提交
+	return branch, commit, nil
+}
+
+// resolveRef 解析引用（例如 refs/heads/main）对应的提交，依次查找松散引用和 packed-refs
+func (r *gitRepo) resolveRef(ref string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		value, err := r.readRef(ref)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(value, "ref:") {
+			return value, nil
+		}
+		ref = strings.TrimSpace(strings.TrimPrefix(value, "ref:"))
+	}
+	return "", fmt.Errorf("too many symbolic refs: %s", ref)
+}
+
+// readRef 读取单个引用的内容
+func (r *gitRepo) readRef(ref string) (string, error) {
+	for _, dir := range []string{r.Dir, r.CommonDir} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+
+	file, err := os.Open(filepath.Join(r.CommonDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("unknown ref: %s", ref)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("unknown ref: %s", ref)
+}
+
+// config 读取仓库配置，键为小写的 section.subsection.key（subsection 保持原样）
+func (r *gitRepo) config() (map[string]string, error) {
+	file, err := os.Open(filepath.Join(r.CommonDir, "config"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// [remote "origin"] -> remote.origin
+			header := strings.TrimSpace(line[1 : len(line)-1])
+			name, sub, _ := strings.Cut(header, " ")
+			section = strings.ToLower(name)
+			if sub = strings.Trim(strings.TrimSpace(sub), `"`); sub != "" {
+				section += "." + sub
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		values[section+"."+key] = value
+	}
+	return values, scanner.Err()
+}
+
+// remoteURL 返回用于生成网页链接的远程地址
+// 顺序：当前分支的上游远程 -> origin -> 按名称排序的第一个远程
+func (r *gitRepo) remoteURL(branch string) (string, error) {
+	cfg, err := r.config()
+	if err != nil {
+		return "", fmt.Errorf("cannot read Git config: %v", err)
+	}
+
+	if name := cfg["branch."+branch+".remote"]; branch != "" && name != "" {
+		if u := cfg["remote."+name+".url"]; u != "" {
+			return u, nil
+		}
+	}
+	if u := cfg["remote.origin.url"]; u != "" {
+		return u, nil
+	}
+
+	var keys []string
+	for key := range cfg {
+		if strings.HasPrefix(key, "remote.") && strings.HasSuffix(key, ".url") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("repository has no remotes: %s", r.Root)
+	}
+	sort.Strings(keys)
+	return cfg[keys[0]], nil
+}
+
+// parseRemoteURL 把远程地址转换为网页地址的主机和仓库路径
+// 支持 https://host/owner/repo.git、ssh://git@host:22/owner/repo.git 和 git@host:owner/repo.git
+func parseRemoteURL(remote string) (string, string, error) {
+	var host, repoPath string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remote URL: %s: %v", remote, err)
+		}
+		host, repoPath = u.Hostname(), u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			host = u.Host // 网页地址保留非默认端口
+		}
+	} else if m := scpRemotePattern.FindStringSubmatch(remote); m != nil {
+		host, repoPath = m[1], m[2]
+	} else {
+		return "", "", fmt.Errorf("unsupported remote URL: %s", remote)
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || repoPath == "" {
+		return "", "", fmt.Errorf("unsupported remote URL: %s", remote)
+	}
+	return host, repoPath, nil
+}
+
+// forgeForHost 判断主机使用的代码托管平台，git_forges 配置优先，其次按主机名猜测
+func forgeForHost(host string) (string, error) {
+	name := strings.ToLower(host)
+	// 主机名包含 .，不能作为配置的键，因此按平台类型列出主机
+	for forge, hosts := range config.GitForges {
+		for _, h := range hosts {
+			if strings.EqualFold(h, host) || strings.EqualFold(h, strings.Split(host, ":")[0]) {
+				return strings.ToLower(forge), nil
+			}
+		}
+	}
+
+	switch {
+	case strings.Contains(name, "github"):
+		return forgeGitHub, nil
+	case strings.Contains(name, "gitlab"):
+		return forgeGitLab, nil
+	case strings.Contains(name, "bitbucket"):
+		return forgeBitbucket, nil
+	case strings.Contains(name, "gitea"), strings.Contains(name, "forgejo"), name == "codeberg.org":
+		return forgeGitea, nil
+	}
+	return "", fmt.Errorf("unknown forge for %s, set it with: of config set-forge %s <%s>", host, host, strings.Join(gitForges, "|"))
+}
+
+// gitWebURL 返回文件（或目录）在代码托管平台上的网页地址
+// 当前在分支上时使用分支名，分离 HEAD 时使用提交哈希；blame 为 true 时返回 blame 页面
+func gitWebURL(t templateContext, blame bool) (string, error) {
+	repo, err := openGitRepo(t.Path)
+	if err != nil {
+		return "", err
+	}
+	branch, commit, err := repo.head()
+	if err != nil {
+		return "", err
+	}
+	ref := branch
+	if ref == "" {
+		ref = commit
+	}
+
+	remote, err := repo.remoteURL(branch)
+	if err != nil {
+		return "", err
+	}
+	host, repoPath, err := parseRemoteURL(remote)
+	if err != nil {
+		return "", err
+	}
+	forge, err := forgeForHost(host)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(repo.Root, t.Path)
+	if err != nil {
+		return "", err
+	}
+	rel = escapeURLPath(filepath.ToSlash(rel))
+	if rel == "." {
+		rel = ""
+	}
+	dir := isDirectory(t.Path)
+
+	if debug {
+		fmt.Printf("🔍 Git: root=%s remote=%s forge=%s ref=%s\n", repo.Root, remote, forge, ref)
+	}
+
+	base := "https://" + host + "/" + repoPath
+	escapedRef := escapeURLPath(ref)
+	var link string
+	switch forge {
+	case forgeGitLab:
+		kind := "blob"
+		switch {
+		case blame && !dir:
+			kind = "blame"
+		case dir:
+			kind = "tree"
+		}
+		link = base + "/-/" + kind + "/" + escapedRef + "/" + rel
+		if t.Line > 0 {
+			link += "#L" + strconv.Itoa(t.Line)
+		}
+	case forgeGitea:
+		kind := "src"
+		if blame && !dir {
+			kind = "blame"
+		}
+		refKind := "branch"
+		if branch == "" {
+			refKind = "commit"
+		}
+		link = base + "/" + kind + "/" + refKind + "/" + escapedRef + "/" + rel
+		if t.Line > 0 {
+			link += "#L" + strconv.Itoa(t.Line)
+		}
+	case forgeBitbucket:
+		kind := "src"
+		if blame && !dir {
+			kind = "annotate"
+		}
+		link = base + "/" + kind + "/" + escapedRef + "/" + rel
+		if t.Line > 0 {
+			link += "#lines-" + strconv.Itoa(t.Line)
+		}
+	default:
+		kind := "blob"
+		switch {
+		case blame && !dir:
+			kind = "blame"
+		case dir:
+			kind = "tree"
+		}
+		link = base + "/" + kind + "/" + escapedRef + "/" + rel
+		if t.Line > 0 {
+			link += "#L" + strconv.Itoa(t.Line)
+		}
+	}
+	return strings.TrimSuffix(link, "/"), nil
+}
+
+// escapeURLPath 逐段转义路径，保留分隔符 /
+func escapeURLPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// gitTargets 把目标转换为仓库根目录（--git-root）或网页地址（--web）
+func gitTargets(targets []templateContext, root, web, blame bool) ([]templateContext, []error) {
+	var (
+		result   []templateContext
+		failures []error
+		seen     = map[string]bool{}
+	)
+
+	for _, t := range targets {
+		if t.URL != "" {
+			failures = append(failures, fmt.Errorf("not a local path: %s", t.URL))
+			continue
+		}
+
+		if root {
+			dir := t.Path
+			if !isDirectory(dir) {
+				dir = filepath.Dir(dir)
+			}
+			gitRoot, ok := findGitRoot(dir)
+			if !ok {
+				failures = append(failures, fmt.Errorf("not inside a Git repository: %s", t.Path))
+				continue
+			}
+			t = templateContext{Path: gitRoot}
+		}
+
+		if web {
+			link, err := gitWebURL(t, blame)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s: %v", formatPath(t.Path), err))
+				continue
+			}
+			t = templateContext{Path: link, URL: link}
+		}
+
+		if !seen[t.Path] {
+			seen[t.Path] = true
+			result = append(result, t)
+		}
+	}
+	return result, failures
+}
@@ -0,0 +1,540 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 读取 Git 对象：松散对象（objects/xx/...）和打包对象（objects/pack/*.idx + *.pack，包括增量对象）
+
+// Git 对象类型（打包文件中的编号）
+const (
+	gitObjCommit   = 1
+	gitObjTree     = 2
+	gitObjBlob     = 3
+	gitObjTag      = 4
+	gitObjOfsDelta = 6
+	gitObjRefDelta = 7
+)
+
+var gitObjectTypes = map[int]string{gitObjCommit: "commit", gitObjTree: "tree", gitObjBlob: "blob", gitObjTag: "tag"}
+
+// maxDeltaDepth 增量链的最大深度，防止损坏的打包文件导致无限递归
+const maxDeltaDepth = 64
+
+// hexPattern 匹配（可能缩写的）对象哈希
+var hexPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// fullHashPattern 匹配完整的对象哈希
+var fullHashPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// revSuffixPattern 匹配版本表达式末尾的 ~N 或 ^N
+var revSuffixPattern = regexp.MustCompile(`([~^])(\d*)$`)
+
+// readObject 读取对象，返回类型和内容
+func (r *gitRepo) readObject(sha string) (string, []byte, error) {
+	return r.readObjectDepth(sha, 0)
+}
+
+func (r *gitRepo) readObjectDepth(sha string, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, fmt.Errorf("delta chain too deep: %s", sha)
+	}
+
+	// 松散对象
+	loose := filepath.Join(r.CommonDir, "objects", sha[:2], sha[2:])
+	if file, err := os.Open(loose); err == nil {
+		defer file.Close()
+		return readLooseObject(file)
+	}
+
+	// 打包对象
+	packs, err := r.packIndexes()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, idx := range packs {
+		offset, found, err := idx.find(sha)
+		if err != nil {
+			return "", nil, err
+		}
+		if found {
+			return r.readPackObject(idx.pack, offset, depth)
+		}
+	}
+	return "", nil, fmt.Errorf("object not found: %s", sha)
+}
+
+// readLooseObject 解压松散对象："<类型> <大小>\0<内容>"
+func readLooseObject(file io.Reader) (string, []byte, error) {
+	z, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer z.Close()
+
+	data, err := io.ReadAll(z)
+	if err != nil {
+		return "", nil, err
+	}
+	header, body, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("invalid loose object")
+	}
+	kind, _, _ := strings.Cut(string(header), " ")
+	return kind, body, nil
+}
+
+// packIndexes 返回所有打包索引，第一次调用时读取，之后复用
+func (r *gitRepo) packIndexes() ([]*packIndex, error) {
+	if r.packs != nil {
+		return r.packs, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(r.CommonDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	packs := make([]*packIndex, 0, len(matches))
+	for _, path := range matches {
+		idx, err := loadPackIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, idx)
+	}
+	r.packs = packs
+	return packs, nil
+}
+
+// packIndex 第 2 版打包索引中用到的部分
+type packIndex struct {
+	pack  string // 对应的打包文件
+	data  []byte
+	count int
+}
+
+// loadPackIndex 读取并校验打包索引（只支持第 2 版）
+func loadPackIndex(path string) (*packIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("unsupported pack index: %s", path)
+	}
+	count := int(binary.BigEndian.Uint32(data[8+255*4:]))
+	if len(data) < 8+256*4+count*(20+4+4) {
+		return nil, fmt.Errorf("truncated pack index: %s", path)
+	}
+	return &packIndex{pack: strings.TrimSuffix(path, ".idx") + ".pack", data: data, count: count}, nil
+}
+
+// hash 返回第 i 个对象的哈希
+func (p *packIndex) hash(i int) []byte {
+	start := 8 + 256*4 + i*20
+	return p.data[start : start+20]
+}
+
+// offset 返回第 i 个对象在打包文件中的偏移
+func (p *packIndex) offset(i int) int64 {
+	base := 8 + 256*4 + p.count*(20+4)
+	off := binary.BigEndian.Uint32(p.data[base+i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off)
+	}
+	// 超过 2GB 的偏移保存在 64 位偏移表中
+	large := base + p.count*4 + int(off&0x7fffffff)*8
+	return int64(binary.BigEndian.Uint64(p.data[large:]))
+}
+
+// find 在索引中查找完整哈希，返回对象在打包文件中的偏移
+func (p *packIndex) find(sha string) (int64, bool, error) {
+	want, err := hex.DecodeString(sha)
+	if err != nil || len(want) != 20 {
+		return 0, false, fmt.Errorf("invalid object name: %s", sha)
+	}
+	i := sort.Search(p.count, func(i int) bool { return bytes.Compare(p.hash(i), want) >= 0 })
+	if i < p.count && bytes.Equal(p.hash(i), want) {
+		return p.offset(i), true, nil
+	}
+	return 0, false, nil
+}
+
+// readPackObject 读取打包文件中 offset 处的对象，增量对象会被还原
+func (r *gitRepo) readPackObject(packPath string, offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, fmt.Errorf("delta chain too deep in %s", packPath)
+	}
+
+	file, err := os.Open(packPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62))
+
+	// 类型和大小：第一个字节的 4-6 位为类型，其余为变长编码的大小
+	b, err := reader.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	kind := int(b>>4) & 7
+	for b&0x80 != 0 {
+		if b, err = reader.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	switch kind {
+	case gitObjCommit, gitObjTree, gitObjBlob, gitObjTag:
+		data, err := inflate(reader)
+		return gitObjectTypes[kind], data, err
+
+	case gitObjOfsDelta:
+		// 基础对象位于当前对象之前的相对偏移处
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = reader.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+		delta, err := inflate(reader)
+		if err != nil {
+			return "", nil, err
+		}
+		baseKind, base, err := r.readPackObject(packPath, offset-rel, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseKind, data, err
+
+	case gitObjRefDelta:
+		// 基础对象通过哈希引用，可能在其他打包文件中
+		var baseSHA [20]byte
+		if _, err := io.ReadFull(reader, baseSHA[:]); err != nil {
+			return "", nil, err
+		}
+		delta, err := inflate(reader)
+		if err != nil {
+			return "", nil, err
+		}
+		baseKind, base, err := r.readObjectDepth(hex.EncodeToString(baseSHA[:]), depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseKind, data, err
+	}
+	return "", nil, fmt.Errorf("unknown object type %d in %s", kind, packPath)
+}
+
+// inflate 解压 zlib 数据
+func inflate(reader io.Reader) ([]byte, error) {
+	z, err := zlib.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return io.ReadAll(z)
+}
+
+// applyDelta 把增量数据应用到基础对象上
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+	readSize := func() int {
+		size, shift := 0, 0
+		for pos < len(delta) {
+			b := delta[pos]
+			pos++
+			size |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		return size
+	}
+
+	if readSize() != len(base) {
+		return nil, fmt.Errorf("delta base size mismatch")
+	}
+	result := make([]byte, 0, readSize())
+
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 == 0 {
+			// 插入：op 为字面数据的长度
+			n := int(op)
+			if n == 0 || pos+n > len(delta) {
+				return nil, fmt.Errorf("invalid delta")
+			}
+			result = append(result, delta[pos:pos+n]...)
+			pos += n
+			continue
+		}
+
+		// 复制：低 4 位标记偏移的字节，接下来 3 位标记大小的字节
+		var offset, size int
+		for i := 0; i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if pos >= len(delta) {
+					return nil, fmt.Errorf("invalid delta")
+				}
+				offset |= int(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if op&(1<<(4+i)) != 0 {
+				if pos >= len(delta) {
+					return nil, fmt.Errorf("invalid delta")
+				}
+				size |= int(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, fmt.Errorf("invalid delta")
+		}
+		result = append(result, base[offset:offset+size]...)
+	}
+	return result, nil
+}
+
+// resolveRevision 把版本表达式解析为提交哈希
+// 支持 HEAD、分支、标签、远程分支、（缩写的）哈希，以及 ~N 和 ^N 后缀
+func (r *gitRepo) resolveRevision(rev string) (string, error) {
+	if m := revSuffixPattern.FindStringSubmatchIndex(rev); m != nil && m[0] > 0 {
+		n := 1
+		if m[4] != m[5] {
+			n, _ = strconv.Atoi(rev[m[4]:m[5]])
+		}
+		commit, err := r.resolveRevision(rev[:m[0]])
+		if err != nil {
+			return "", err
+		}
+		if rev[m[2]:m[3]] == "~" {
+			// ~N：沿第一个父提交向上 N 次
+			for i := 0; i < n; i++ {
+				if commit, err = r.parentCommit(commit, 1); err != nil {
+					return "", err
+				}
+			}
+			return commit, nil
+		}
+		if n == 0 {
+			return commit, nil
+		}
+		// ^N：第 N 个父提交
+		return r.parentCommit(commit, n)
+	}
+
+	sha, err := r.resolveName(rev)
+	if err != nil {
+		return "", err
+	}
+	return r.peelToCommit(sha)
+}
+
+// resolveName 解析不带后缀的名称
+func (r *gitRepo) resolveName(name string) (string, error) {
+	if name == "HEAD" || name == "@" {
+		_, commit, err := r.head()
+		if err == nil && commit == "" {
+			err = fmt.Errorf("HEAD has no commits yet")
+		}
+		return commit, err
+	}
+
+	// 与 git 相同的查找顺序；只有 FETCH_HEAD 这类全大写名称才直接在 Git 目录中查找
+	refs := []string{"refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	if strings.HasPrefix(name, "refs/") || name == strings.ToUpper(name) {
+		refs = append([]string{name}, refs...)
+	}
+	for _, ref := range refs {
+		if sha, err := r.resolveRef(ref); err == nil && fullHashPattern.MatchString(sha) {
+			return sha, nil
+		}
+	}
+
+	if hexPattern.MatchString(name) {
+		return r.expandObjectName(strings.ToLower(name))
+	}
+	return "", fmt.Errorf("unknown revision: %s", name)
+}
+
+// expandObjectName 把缩写的哈希展开为完整哈希，有多个匹配时报错
+func (r *gitRepo) expandObjectName(prefix string) (string, error) {
+	if len(prefix) == 40 {
+		return prefix, nil
+	}
+
+	matches := map[string]bool{}
+	entries, _ := os.ReadDir(filepath.Join(r.CommonDir, "objects", prefix[:2]))
+	for _, entry := range entries {
+		if full := prefix[:2] + entry.Name(); strings.HasPrefix(full, prefix) {
+			matches[full] = true
+		}
+	}
+
+	packs, err := r.packIndexes()
+	if err != nil {
+		return "", err
+	}
+	for _, idx := range packs {
+		start := sort.Search(idx.count, func(i int) bool { return hex.EncodeToString(idx.hash(i)) >= prefix })
+		for i := start; i < idx.count; i++ {
+			full := hex.EncodeToString(idx.hash(i))
+			if !strings.HasPrefix(full, prefix) {
+				break
+			}
+			matches[full] = true
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown revision: %s", prefix)
+	case 1:
+		for full := range matches {
+			return full, nil
+		}
+	}
+	return "", fmt.Errorf("ambiguous revision: %s", prefix)
+}
+
+// peelToCommit 把附注标签解引用到提交
+func (r *gitRepo) peelToCommit(sha string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		kind, data, err := r.readObject(sha)
+		if err != nil {
+			return "", err
+		}
+		switch kind {
+		case "commit":
+			return sha, nil
+		case "tag":
+			target, ok := objectHeader(data, "object")
+			if !ok {
+				return "", fmt.Errorf("invalid tag object: %s", sha)
+			}
+			sha = target
+		default:
+			return "", fmt.Errorf("%s is a %s, not a commit", sha, kind)
+		}
+	}
+	return "", fmt.Errorf("too many nested tags: %s", sha)
+}
+
+// parentCommit 返回第 n 个父提交
+func (r *gitRepo) parentCommit(commit string, n int) (string, error) {
+	_, data, err := r.readObject(commit)
+	if err != nil {
+		return "", err
+	}
+	count := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break // 头部结束
+		}
+		if parent, ok := strings.CutPrefix(line, "parent "); ok {
+			count++
+			if count == n {
+				return parent, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("commit %s has no parent %d", commit[:7], n)
+}
+
+// objectHeader 返回提交或标签对象头部中某个字段的值
+func objectHeader(data []byte, field string) (string, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, field+" "); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// readFileAtCommit 读取提交中某个文件（相对于仓库根目录，使用 /）的内容
+func (r *gitRepo) readFileAtCommit(commit, relPath string) ([]byte, error) {
+	_, data, err := r.readObject(commit)
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := objectHeader(data, "tree")
+	if !ok {
+		return nil, fmt.Errorf("invalid commit: %s", commit)
+	}
+
+	parts := strings.Split(relPath, "/")
+	sha := tree
+	for i, name := range parts {
+		kind, data, err := r.readObject(sha)
+		if err != nil {
+			return nil, err
+		}
+		if kind != "tree" {
+			return nil, fmt.Errorf("%s is not a directory", strings.Join(parts[:i], "/"))
+		}
+		entry, found := findTreeEntry(data, name)
+		if !found {
+			return nil, fmt.Errorf("path does not exist at %s: %s", commit[:7], relPath)
+		}
+		sha = entry
+	}
+
+	kind, blob, err := r.readObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "blob" {
+		return nil, fmt.Errorf("not a file at %s: %s", commit[:7], relPath)
+	}
+	return blob, nil
+}
+
+// findTreeEntry 在树对象中查找条目："<模式> <名称>\0<20 字节哈希>"
+func findTreeEntry(tree []byte, name string) (string, bool) {
+	for len(tree) > 0 {
+		header, rest, ok := bytes.Cut(tree, []byte{0})
+		if !ok || len(rest) < 20 {
+			return "", false
+		}
+		_, entryName, _ := strings.Cut(string(header), " ")
+		if entryName == name {
+			return hex.EncodeToString(rest[:20]), true
+		}
+		tree = rest[20:]
+	}
+	return "", false
+}
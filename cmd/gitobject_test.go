@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello, world\n")
+
+	tests := []struct {
+		name    string
+		delta   []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "copy all",
+			delta: []byte{13, 13, 0x90, 13},
+			want:  "hello, world\n",
+		},
+		{
+			name:  "insert only",
+			delta: []byte{13, 3, 3, 'a', 'b', 'c'},
+			want:  "abc",
+		},
+		{
+			name: "copy, insert, copy",
+			// 复制 "hello"，插入 " there"，从偏移 5 复制 ", world\n"
+			delta: []byte{13, 19, 0x90, 5, 6, ' ', 't', 'h', 'e', 'r', 'e', 0x91, 5, 8},
+			want:  "hello there, world\n",
+		},
+		{
+			name:    "truncated insert",
+			delta:   []byte{13, 0x82, 0x01, 0x90, 13, 0x7f, 'x'},
+			wantErr: true,
+		},
+		{
+			name:    "base size mismatch",
+			delta:   []byte{12, 12, 0x90, 12},
+			wantErr: true,
+		},
+		{
+			name:    "copy out of range",
+			delta:   []byte{13, 4, 0x91, 12, 4},
+			wantErr: true,
+		},
+		{
+			name:    "truncated copy",
+			delta:   []byte{13, 4, 0x91},
+			wantErr: true,
+		},
+		{
+			name:    "zero insert",
+			delta:   []byte{13, 0, 0},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyDelta(base, tt.delta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyDelta error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("applyDelta = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDeltaLargeCopy(t *testing.T) {
+	// 复制大小为 0 表示 0x10000 字节
+	base := bytes.Repeat([]byte("x"), 0x10000)
+	delta := []byte{0x80, 0x80, 0x04, 0x80, 0x80, 0x04, 0x80}
+	got, err := applyDelta(base, delta)
+	if err != nil {
+		t.Fatalf("applyDelta: %v", err)
+	}
+	if !bytes.Equal(got, base) {
+		t.Errorf("applyDelta returned %d bytes, want %d", len(got), len(base))
+	}
+}
+
+// testRepo 用 git 命令创建测试仓库，没有安装 git 时跳过
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q", "-b", "main")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=of", "-c", "user.email=of@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+r.dir)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (r *testRepo) commit(message string, files map[string]string) {
+	r.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(r.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", message)
+}
+
+func (r *testRepo) open() *gitRepo {
+	r.t.Helper()
+	repo, err := openGitRepo(r.dir)
+	if err != nil {
+		r.t.Fatalf("openGitRepo: %v", err)
+	}
+	return repo
+}
+
+// bigFile 生成足够大、只有一行不同的文件内容，打包时会存为增量对象
+func bigFile(version int) string {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		if i == 100 {
+			fmt.Fprintf(&b, "version %d\n", version)
+			continue
+		}
+		fmt.Fprintf(&b, "line %d: the quick brown fox jumps over the lazy dog\n", i)
+	}
+	return b.String()
+}
+
+// buildHistory 创建带分支、合并和标签的提交历史
+func buildHistory(r *testRepo) {
+	r.commit("one", map[string]string{"big.txt": bigFile(1), "src/a.go": "package a\n"})
+	r.git("tag", "-a", "v1", "-m", "v1")
+	r.commit("two", map[string]string{"big.txt": bigFile(2)})
+	r.git("checkout", "-q", "-b", "feature")
+	r.commit("feature", map[string]string{"src/b.go": "package b\n"})
+	r.git("checkout", "-q", "main")
+	r.commit("three", map[string]string{"big.txt": bigFile(3)})
+	r.git("merge", "-q", "--no-ff", "-m", "merge", "feature")
+	r.git("tag", "light")
+	r.commit("four", map[string]string{"big.txt": bigFile(4), "src/a.go": "package a\n\nfunc A() {}\n"})
+}
+
+func TestResolveRevision(t *testing.T) {
+	revs := []string{
+		"HEAD", "@", "main", "feature", "v1", "light", "refs/tags/v1", "tags/v1", "heads/main",
+		"HEAD~1", "HEAD~2", "HEAD~3", "HEAD^", "HEAD~1^2", "light^1", "light^2", "HEAD~", "HEAD^0",
+		"main~1~1", "v1~0",
+	}
+
+	for _, layout := range []string{"loose", "packed", "packed with ref deltas"} {
+		t.Run(layout, func(t *testing.T) {
+			r := newTestRepo(t)
+			buildHistory(r)
+			switch layout {
+			case "packed":
+				r.git("gc", "-q", "--aggressive")
+			case "packed with ref deltas":
+				r.git("-c", "repack.useDeltaBaseOffset=false", "repack", "-q", "-a", "-d", "-f")
+				r.git("pack-refs", "--all")
+			}
+			repo := r.open()
+
+			for _, rev := range revs {
+				want := r.git("rev-parse", rev+"^{commit}")
+				got, err := repo.resolveRevision(rev)
+				if err != nil {
+					t.Errorf("resolveRevision(%q): %v", rev, err)
+					continue
+				}
+				if got != want {
+					t.Errorf("resolveRevision(%q) = %s, want %s", rev, got, want)
+				}
+			}
+
+			// 缩写的哈希
+			full := r.git("rev-parse", "HEAD~2")
+			if got, err := repo.resolveRevision(full[:8]); err != nil || got != full {
+				t.Errorf("resolveRevision(%q) = %s, %v; want %s", full[:8], got, err, full)
+			}
+
+			for _, rev := range []string{"missing", "HEAD~10", "v1^2", "deadbeef"} {
+				if got, err := repo.resolveRevision(rev); err == nil {
+					t.Errorf("resolveRevision(%q) = %s, want an error", rev, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileAtCommit(t *testing.T) {
+	for _, packed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("packed=%v", packed), func(t *testing.T) {
+			r := newTestRepo(t)
+			buildHistory(r)
+			if packed {
+				r.git("gc", "-q", "--aggressive")
+				if matches, _ := filepath.Glob(filepath.Join(r.dir, ".git", "objects", "pack", "*.idx")); len(matches) == 0 {
+					t.Fatal("git gc did not create a pack")
+				}
+			}
+			repo := r.open()
+
+			for _, rev := range []string{"HEAD", "HEAD~1", "HEAD~2", "HEAD~3", "v1"} {
+				for _, path := range []string{"big.txt", "src/a.go"} {
+					commit, err := repo.resolveRevision(rev)
+					if err != nil {
+						t.Fatalf("resolveRevision(%q): %v", rev, err)
+					}
+					got, err := repo.readFileAtCommit(commit, path)
+					if err != nil {
+						t.Errorf("readFileAtCommit(%s, %s): %v", rev, path, err)
+						continue
+					}
+					if want := r.git("show", rev+":"+path); strings.TrimSpace(string(got)) != want {
+						t.Errorf("readFileAtCommit(%s, %s) does not match git show", rev, path)
+					}
+				}
+			}
+
+			head, _ := repo.resolveRevision("HEAD")
+			for _, path := range []string{"missing.txt", "src", "big.txt/x", "src/missing.go"} {
+				if _, err := repo.readFileAtCommit(head, path); err == nil {
+					t.Errorf("readFileAtCommit(HEAD, %s): expected an error", path)
+				}
+			}
+			v1, _ := repo.resolveRevision("v1")
+			if _, err := repo.readFileAtCommit(v1, "src/b.go"); err == nil {
+				t.Error("readFileAtCommit(v1, src/b.go): expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadPackIndexInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string][]byte{
+		"empty":     nil,
+		"version 1": append([]byte{0xff, 't', 'O', 'c', 0, 0, 0, 1}, make([]byte, 256*4)...),
+		"truncated": append(append([]byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}, make([]byte, 255*4)...), 0, 0, 0, 5),
+	}
+	for name, data := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".idx")
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadPackIndex(path); err == nil {
+			t.Errorf("loadPackIndex(%s): expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// 没有图形界面（SSH 会话，或没有 DISPLAY/WAYLAND_DISPLAY）时，平台默认的打开方式
+// 要么失败，要么把窗口开在没人看的远程显示器上，此时改用 headless_fallback 策略：
+//
+//	print      输出路径（默认）
+//	osc52      通过 OSC 52 把路径复制到本地终端的剪切板
+//	terminal   在当前终端运行 headless_command（例如 "less {path}"）
+//	hyperlink  输出可点击的 OSC 8 超链接
+//	none       不做处理，仍然使用平台默认的打开方式
+const (
+	headlessPrint     = "print"
+	headlessOSC52     = "osc52"
+	headlessTerminal  = "terminal"
+	headlessHyperlink = "hyperlink"
+	headlessNone      = "none"
+)
+
+var headlessPolicies = []string{headlessPrint, headlessOSC52, headlessTerminal, headlessHyperlink, headlessNone}
+
+var headlessDebugOnce sync.Once
+
+// headlessReason 返回判定为无图形界面的原因，有图形界面时返回空字符串
+func headlessReason() string {
+	switch {
+	case os.Getenv("SSH_CONNECTION") != "":
+		return "SSH_CONNECTION is set"
+	case os.Getenv("SSH_TTY") != "":
+		return "SSH_TTY is set"
+	case !hasGraphicalSession():
+		return "no DISPLAY or WAYLAND_DISPLAY"
+	}
+	return ""
+}
+
+// headlessPolicy 返回配置的回退策略，未配置或无效时使用 print
+func headlessPolicy() string {
+	policy := strings.ToLower(config.HeadlessFallback)
+	if !containsString(headlessPolicies, policy) {
+		return headlessPrint
+	}
+	return policy
+}
+
+// useHeadlessFallback 判断是否应该用回退策略代替平台默认的打开方式
+func useHeadlessFallback() bool {
+	reason := headlessReason()
+	if reason == "" || headlessPolicy() == headlessNone {
+		return false
+	}
+
+	headlessDebugOnce.Do(func() {
+		if debug {
+			fmt.Printf("🔍 Headless session (%s), fallback policy: %s\n", reason, headlessPolicy())
+		}
+	})
+	return true
+}
+
+// headlessLabel 返回回退策略的显示名称
+func headlessLabel() string {
+	switch headlessPolicy() {
+	case headlessOSC52:
+		return "clipboard (OSC 52)"
+	case headlessTerminal:
+		return commandProgram(config.HeadlessCommand)
+	case headlessHyperlink:
+		return "hyperlink (OSC 8)"
+	default:
+		return "stdout"
+	}
+}
+
+// headlessOpen 按回退策略处理路径或 URL
+func headlessOpen(target string) error {
+	switch headlessPolicy() {
+	case headlessOSC52:
+		return writeOSC52(target)
+	case headlessTerminal:
+		if config.HeadlessCommand == "" {
+			return fmt.Errorf("⚠️ headless_fallback is terminal but headless_command is not set")
+		}
+		ctx := templateContext{Path: target}
+		if uriScheme(target) != "" {
+			ctx.URL = target
+		}
+		return launchTemplate(config.HeadlessCommand, ctx, true)
+	case headlessHyperlink:
+		uri := target
+		if uriScheme(target) == "" {
+			uri = hostFileURI(target)
+		}
+		if isTerminal(os.Stdout) {
+			return launcher.Print(TerminalText{Text: "🔗 " + osc8Link(uri, formatPath(target)) + "\n", Description: "hyperlink to " + uri})
+		}
+		return launcher.Print(TerminalText{Text: uri + "\n"})
+	default:
+		return launcher.Print(TerminalText{Text: target + "\n"})
+	}
+}
+
+// hostFileURI 返回带主机名的 file:// URI，方便本地终端识别远程文件
+func hostFileURI(path string) string {
+	uri := fileURI(path)
+	if host, err := os.Hostname(); err == nil && host != "" {
+		uri = "file://" + host + strings.TrimPrefix(uri, "file://")
+	}
+	return uri
+}
+
+// osc8Link 返回 OSC 8 终端超链接
+func osc8Link(uri, text string) string {
+	return "\x1b]8;;" + uri + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// writeOSC52 通过 OSC 52 转义序列让终端把文本写入本地剪切板（SSH 中同样有效）
+func writeOSC52(text string) error {
+	return launcher.Print(TerminalText{
+		Text:        osc52Sequence(text),
+		TTY:         true,
+		Description: fmt.Sprintf("OSC 52 sequence copying %q", text),
+	})
+}
+
+// openTTY 打开当前控制终端，标准输出被重定向时转义序列仍然能到达终端
+func openTTY() (*os.File, error) {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONOUT$"
+	}
+	tty, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("⚠️ no terminal available: %v", err)
+	}
+	return tty, nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyEntry 历史记录中的一条路径
+// Rank 每次访问加 1，LastAccess 为最后访问时间（Unix 秒）
+type historyEntry struct {
+	Path       string  `mapstructure:"path" yaml:"path" json:"path"`
+	Rank       float64 `mapstructure:"rank" yaml:"rank" json:"rank"`
+	LastAccess int64   `mapstructure:"last_access" yaml:"last_access" json:"last_access"`
+}
+
+// defaultHistoryMaxAge 所有条目 Rank 之和的上限，与 zoxide 的 _ZO_MAXAGE 默认值一致
+const defaultHistoryMaxAge = 10000
+
+// frecency 根据访问次数和最后访问时间计算得分（与 zoxide 相同的分段衰减）
+func (e historyEntry) frecency(now time.Time) float64 {
+	age := now.Sub(time.Unix(e.LastAccess, 0))
+	switch {
+	case age < time.Hour:
+		return e.Rank * 4
+	case age < 24*time.Hour:
+		return e.Rank * 2
+	case age < 7*24*time.Hour:
+		return e.Rank / 2
+	default:
+		return e.Rank / 4
+	}
+}
+
+// addToHistory 记录一次访问
+func addToHistory(paths ...string) {
+	if len(paths) == 0 || dryRun {
+		return
+	}
+	now := time.Now().Unix()
+	maxAge := historyMaxAge()
+
+	err := updateHistory(func(entries []historyEntry) []historyEntry {
+		for _, path := range paths {
+			found := false
+			for i := range entries {
+				if entries[i].Path == path {
+					entries[i].Rank++
+					entries[i].LastAccess = now
+					found = true
+					break
+				}
+			}
+			if !found {
+				entries = append(entries, historyEntry{Path: path, Rank: 1, LastAccess: now})
+			}
+		}
+		return ageHistory(entries, maxAge)
+	})
+	warnHistoryError(err)
+}
+
+// historyMaxAge 返回配置的 Rank 总和上限
+func historyMaxAge() float64 {
+	if config.HistoryMaxAge > 0 {
+		return config.HistoryMaxAge
+	}
+	return defaultHistoryMaxAge
+}
+
+// ageHistory Rank 总和超过上限时按比例衰减，并丢弃 Rank 小于 1 的条目
+func ageHistory(entries []historyEntry, maxAge float64) []historyEntry {
+	total := 0.0
+	for _, e := range entries {
+		total += e.Rank
+	}
+	if total <= maxAge {
+		return entries
+	}
+
+	factor := 0.9 * maxAge / total
+	aged := entries[:0]
+	for _, e := range entries {
+		e.Rank *= factor
+		if e.Rank >= 1 {
+			aged = append(aged, e)
+		}
+	}
+	return aged
+}
+
+// removeFromHistory 删除指定路径，返回是否存在
+func removeFromHistory(path string) bool {
+	removed := false
+	err := updateHistory(func(entries []historyEntry) []historyEntry {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Path == path {
+				removed = true
+				continue
+			}
+			kept = append(kept, e)
+		}
+		return kept
+	})
+	warnHistoryError(err)
+	return removed && err == nil
+}
+
+// clearHistory 清空历史记录
+func clearHistory() error {
+	return updateHistory(func(entries []historyEntry) []historyEntry {
+		return []historyEntry{}
+	})
+}
+
+// recentHistory 返回按最后访问时间排序的历史记录，不存在的路径会被清理
+func recentHistory() []historyEntry {
+	entries := validHistory()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastAccess > entries[j].LastAccess
+	})
+	return entries
+}
+
+// rankedHistory 返回按 frecency 得分排序的历史记录，不存在的路径会被清理
+func rankedHistory() []historyEntry {
+	now := time.Now()
+	entries := validHistory()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].frecency(now) > entries[j].frecency(now)
+	})
+	return entries
+}
+
+// validHistory 返回仍然存在的历史路径，并移除已失效的条目
+func validHistory() []historyEntry {
+	entries := loadHistory()
+	valid := make([]historyEntry, 0, len(entries))
+	for _, e := range entries {
+		if isPathValid(e.Path) {
+			valid = append(valid, e)
+		}
+	}
+
+	if len(valid) != len(entries) {
+		err := updateHistory(func(current []historyEntry) []historyEntry {
+			kept := current[:0]
+			for _, e := range current {
+				if isPathValid(e.Path) {
+					kept = append(kept, e)
+				}
+			}
+			return kept
+		})
+		warnHistoryError(err)
+	}
+	return valid
+}
+
+// matchHistory 按关键字查找历史路径（与 zoxide 的匹配规则相同）：
+// 关键字不区分大小写并按顺序出现在路径中，最后一个关键字必须出现在最后一级路径名中
+func matchHistory(keywords []string) []historyEntry {
+	var matches []historyEntry
+	for _, e := range rankedHistory() {
+		if matchKeywords(e.Path, keywords) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// matchKeywords 判断路径是否匹配所有关键字
+func matchKeywords(path string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+
+	lower := strings.ToLower(path)
+	last := strings.ToLower(keywords[len(keywords)-1])
+	if !strings.Contains(strings.ToLower(filepath.Base(path)), last) {
+		return false
+	}
+
+	offset := 0
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(keyword)
+		i := strings.Index(lower[offset:], keyword)
+		if i < 0 {
+			return false
+		}
+		offset += i + len(keyword)
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// 历史记录保存在状态目录下的 JSON Lines 文件中，每行一条 historyEntry。
+// 所有修改都在文件锁保护下进行“读取 -> 修改 -> 写入临时文件 -> 原子重命名”，
+// 因此多个终端同时运行 of 也不会丢失记录，也不会再改写 config.yaml。
+const (
+	historyFileName = "history.jsonl"
+	historyLockName = "history.lock"
+)
+
+// dataDir 保存历史记录的状态目录，由 loadConfig 通过 initDataDir 设置
+var dataDir string
+
+// stateDir 返回保存运行状态的目录
+// 历史记录是状态而不是配置，与 ~/.of 分开存放，同步配置目录时不会同时同步（并破坏）加锁的历史记录文件：
+// Linux 等平台为 $XDG_STATE_HOME/of（默认 ~/.local/state/of），macOS 为 ~/Library/Application Support/of，
+// Windows 为 %LOCALAPPDATA%\of
+func stateDir(home string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "of")
+	case "windows":
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "of")
+		}
+		return filepath.Join(home, "AppData", "Local", "of")
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "of")
+	}
+	return filepath.Join(home, ".local", "state", "of")
+}
+
+// initDataDir 设置状态目录，并把旧版本保存在配置目录中的历史记录文件移动过去
+// 无法创建状态目录或移动失败时继续使用配置目录中的文件；--dry-run 时不创建也不移动
+func initDataDir(home, configDir string) {
+	dataDir = stateDir(home)
+	legacy := filepath.Join(configDir, historyFileName)
+	if _, err := os.Stat(legacy); err != nil {
+		if !dryRun {
+			_ = os.MkdirAll(dataDir, 0700)
+		}
+		return
+	}
+	if _, err := os.Stat(historyFile()); err == nil {
+		return
+	}
+
+	if dryRun {
+		dataDir = configDir
+		return
+	}
+	err := os.MkdirAll(dataDir, 0700)
+	if err == nil {
+		err = os.Rename(legacy, historyFile())
+	}
+	if err != nil {
+		if debug {
+			fmt.Printf("⚠️ Warning: cannot move history to %s, keeping it in %s: %v\n", dataDir, configDir, err)
+		}
+		dataDir = configDir
+		return
+	}
+	_ = os.Remove(filepath.Join(configDir, historyLockName))
+	if debug {
+		fmt.Printf("🔍 Moved history to %s\n", historyFile())
+	}
+}
+
+// historyFile 返回历史记录文件路径
+func historyFile() string {
+	return filepath.Join(dataDir, historyFileName)
+}
+
+// withHistoryLock 在持有历史记录文件锁的情况下执行 fn
+func withHistoryLock(fn func() error) error {
+	if dataDir == "" {
+		return fmt.Errorf("⚠️ data directory is not available")
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dataDir, historyLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return err
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	return fn()
+}
+
+// readHistoryFile 读取历史记录文件，不存在时返回空列表
+// 损坏的行会被跳过；同一路径出现多次时以最后一行为准
+func readHistoryFile() ([]historyEntry, error) {
+	f, err := os.Open(historyFile())
+	if os.IsNotExist(err) {
+		return []historyEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []historyEntry{}
+	index := map[string]int{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Path == "" {
+			if debug && len(scanner.Bytes()) > 0 {
+				fmt.Printf("⚠️ Warning: skipping malformed history line: %s\n", scanner.Text())
+			}
+			continue
+		}
+		if i, exists := index[e.Path]; exists {
+			entries[i] = e
+			continue
+		}
+		index[e.Path] = len(entries)
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// writeHistoryFile 将历史记录写入临时文件后原子重命名
+func writeHistoryFile(entries []historyEntry) error {
+	tmp, err := os.CreateTemp(dataDir, historyFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // 重命名成功后删除会失败，忽略即可
+
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := encoder.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, historyFile())
+}
+
+// loadHistory 读取历史记录（不加锁，写入总是原子重命名，读到的一定是完整文件）
+func loadHistory() []historyEntry {
+	if dataDir == "" {
+		return []historyEntry{}
+	}
+	entries, err := readHistoryFile()
+	if err != nil {
+		if debug {
+			fmt.Printf("⚠️ Warning: cannot read history: %v\n", err)
+		}
+		return []historyEntry{}
+	}
+	return entries
+}
+
+// updateHistory 在文件锁保护下读取、修改并保存历史记录
+func updateHistory(fn func(entries []historyEntry) []historyEntry) error {
+	return withHistoryLock(func() error {
+		entries, err := readHistoryFile()
+		if err != nil {
+			return err
+		}
+		return writeHistoryFile(fn(entries))
+	})
+}
+
+// warnHistoryError 历史记录保存失败不影响打开操作，只在调试模式下提示
+func warnHistoryError(err error) {
+	if err != nil && debug {
+		fmt.Printf("⚠️ Warning: cannot save history: %v\n", err)
+	}
+}
+
+// migrateLegacyHistory 将 config.yaml 中旧版的 recent_paths 和 history 迁移到历史记录文件，
+// 历史记录文件写入成功后才从配置文件中删除这两个键（只会执行一次），写入失败时下次再试
+func migrateLegacyHistory() {
+	if len(config.RecentPaths) == 0 && len(config.History) == 0 {
+		return
+	}
+
+	now := time.Now().Unix()
+	legacy := append([]historyEntry{}, config.History...)
+	for i, path := range config.RecentPaths {
+		// 保留原有顺序：越靠前的路径最后访问时间越新
+		legacy = append(legacy, historyEntry{Path: path, Rank: 1, LastAccess: now - int64(i)})
+	}
+
+	migrated := 0
+	err := updateHistory(func(entries []historyEntry) []historyEntry {
+		for _, old := range legacy {
+			if containsHistoryPath(entries, old.Path) {
+				continue
+			}
+			entries = append(entries, old)
+			migrated++
+		}
+		return entries
+	})
+	if err != nil {
+		if debug {
+			fmt.Printf("⚠️ Warning: cannot migrate legacy history, keeping it in config: %v\n", err)
+		}
+		return
+	}
+
+	if err := removeConfigKeys("recent_paths", "history"); err != nil {
+		if debug {
+			fmt.Printf("⚠️ Warning: cannot remove legacy history from config: %v\n", err)
+		}
+		return
+	}
+	config.RecentPaths = nil
+	config.History = nil
+
+	if debug {
+		fmt.Printf("🔍 Migrated %d legacy history entries to %s\n", migrated, historyFile())
+	}
+}
+
+// containsHistoryPath 判断历史记录中是否已有指定路径
+func containsHistoryPath(entries []historyEntry, path string) bool {
+	for _, e := range entries {
+		if e.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestStateDir(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("XDG_STATE_HOME is only used on Linux and other Unix systems")
+	}
+	state := t.TempDir()
+
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{state, filepath.Join(state, "of")},
+		{"", filepath.Join("/home/me", ".local", "state", "of")},
+		{"relative/state", filepath.Join("/home/me", ".local", "state", "of")},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("XDG_STATE_HOME", tt.env)
+		if got := stateDir("/home/me"); got != tt.want {
+			t.Errorf("stateDir with XDG_STATE_HOME=%q = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestInitDataDir(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("the state directory is set through XDG_STATE_HOME")
+	}
+	savedDataDir, savedDryRun := dataDir, dryRun
+	t.Cleanup(func() { dataDir, dryRun = savedDataDir, savedDryRun })
+
+	tests := []struct {
+		name       string
+		legacy     bool // 配置目录中有旧版的历史记录文件
+		current    bool // 状态目录中已经有历史记录文件
+		dryRun     bool
+		wantLegacy bool // 继续使用配置目录中的文件
+		wantMoved  bool
+	}{
+		{name: "new install"},
+		{name: "move legacy history", legacy: true, wantMoved: true},
+		{name: "keep current history", legacy: true, current: true},
+		{name: "dry run reads legacy history", legacy: true, dryRun: true, wantLegacy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, configDir := t.TempDir(), t.TempDir()
+			t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+			state := stateDir(home)
+			legacy := filepath.Join(configDir, historyFileName)
+			if tt.legacy {
+				if err := os.WriteFile(legacy, []byte(`{"path":"/legacy"}`+"\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.current {
+				if err := os.MkdirAll(state, 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(state, historyFileName), []byte(`{"path":"/current"}`+"\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dryRun = tt.dryRun
+			initDataDir(home, configDir)
+
+			want := state
+			if tt.wantLegacy {
+				want = configDir
+			}
+			if dataDir != want {
+				t.Errorf("dataDir = %s, want %s", dataDir, want)
+			}
+			_, err := os.Stat(legacy)
+			if moved := tt.legacy && os.IsNotExist(err); moved != tt.wantMoved {
+				t.Errorf("legacy history moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if tt.wantMoved {
+				if entries := loadHistory(); len(entries) != 1 || entries[0].Path != "/legacy" {
+					t.Errorf("loadHistory() = %+v, want the legacy entry", entries)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var jumpListOnly bool
+
+var jumpCmd = &cobra.Command{
+	Use:     "j [keywords...]",
+	Aliases: []string{"jump"},
+	Short:   "open the best matching path from history",
+	Long: `Open the highest ranked previously visited path that matches all keywords.
+
+Keywords are case-insensitive and must appear in order; the last keyword
+must match the last component of the path.
+
+Examples:
+  of j proj           # ~/work/project
+  of j work api       # ~/work/backend/api
+  of j -l src         # list all matches with their scores`,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		matches := matchHistory(args)
+		if len(matches) == 0 {
+			fmt.Printf("❌ No history entry matches: %v\n", args)
+			os.Exit(1)
+		}
+
+		if jumpListOnly {
+			now := time.Now()
+			for _, entry := range matches {
+				fmt.Printf("%6.1f  %s\n", entry.frecency(now), formatPath(entry.Path))
+			}
+			return
+		}
+
+		best := matches[0].Path
+		if debug {
+			fmt.Printf("🔍 Best match: %s (%d candidates)\n", best, len(matches))
+		}
+
+		useDefaultManager()
+		usedApp, err := openPath(best)
+		if err != nil {
+			fmt.Printf("❌ Error: cannot open path: %v\n", err)
+			os.Exit(1)
+		}
+		addToHistory(best)
+
+		fmt.Printf("🚀 Opened in %s: %s\n", usedApp, formatPath(best))
+	},
+}
+
+func init() {
+	jumpCmd.Flags().BoolVarP(&jumpListOnly, "list", "l", false, "list matches with scores instead of opening")
+	rootCmd.AddCommand(jumpCmd)
+}
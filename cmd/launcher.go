@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Command 描述一次将要执行的外部命令
+type Command struct {
+	Name       string   // 可执行文件名称或路径
+	Args       []string // 参数列表
+	Stdin      string   // 写入标准输入的内容，为空则不写入
+	IgnoreExit bool     // 忽略非零退出码（例如 Windows explorer 成功时也会返回 1）
+	Detach     bool     // 图形应用：在独立的会话中后台启动，不等待退出（--wait 时改为前台运行）
+	Terminal   bool     // 终端应用：连接到当前终端并等待退出
+}
+
+// Argv 返回完整的命令行参数（包括可执行文件本身）
+func (c Command) Argv() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+// String 返回便于阅读的命令行表示，含空格的参数会被加上引号
+func (c Command) String() string {
+	parts := make([]string, 0, len(c.Args)+1)
+	for _, arg := range c.Argv() {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TerminalText 直接写到标准输出或当前终端的文本（headless 回退策略输出的路径、OSC 52 序列）
+// 与命令一样由启动器处理，--dry-run 和 explain 时不会真正输出
+type TerminalText struct {
+	Text        string // 原样写出的内容
+	TTY         bool   // 写到控制终端（/dev/tty）而不是标准输出
+	Description string // dry-run 时显示的说明，为空时显示 Text
+}
+
+// String 返回便于阅读的表示
+func (t TerminalText) String() string {
+	if t.Description != "" {
+		return t.Description
+	}
+	return strconv.Quote(t.Text)
+}
+
+// appLaunch 一条命令以及它负责打开的路径
+// 应用支持一次接收多个文件时 Paths 包含多个路径，否则每条命令对应一个路径
+type appLaunch struct {
+	Command Command
+	Paths   []string
+}
+
+// Launcher 负责真正执行外部命令
+// 所有打开、复制等操作都通过它完成，测试时可以替换为 FakeLauncher
+type Launcher interface {
+	// LookPath 在 PATH 中查找可执行文件
+	LookPath(file string) (string, error)
+	// Launch 执行命令
+	Launch(c Command) error
+	// Output 执行命令并返回标准输出（例如读取剪切板）
+	Output(c Command) (string, error)
+	// Print 把文本写到标准输出或当前终端
+	Print(t TerminalText) error
+}
+
+// launcher 当前使用的启动器
+var launcher Launcher = execLauncher{}
+
+// SetLauncher 替换全局启动器，返回用于恢复的函数
+func SetLauncher(l Launcher) func() {
+	previous := launcher
+	launcher = l
+	return func() { launcher = previous }
+}
+
+// execLauncher 使用 os/exec 执行命令
+type execLauncher struct{}
+
+func (execLauncher) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+func (execLauncher) Launch(c Command) error {
+	if debug {
+		fmt.Printf("🔍 Exec: %s\n", c)
+	}
+
+	cmd := exec.Command(c.Name, c.Args...)
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+
+	// 终端应用（yazi、lf 等）需要直接使用当前终端
+	if c.Terminal {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	}
+
+	if c.Detach {
+		// --wait：连接到当前终端并等待退出，退出码由调用方传递出去
+		if waitForExit {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			return cmd.Run()
+		}
+
+		// 标准输入输出为 nil 时会连接到空设备，关闭终端也不会影响应用
+		cmd.SysProcAttr = detachedProcAttr()
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		if debug {
+			fmt.Printf("🔍 Detached process: %d\n", cmd.Process.Pid)
+		}
+		return cmd.Process.Release()
+	}
+
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok && c.IgnoreExit {
+		return nil
+	}
+	return err
+}
+
+func (execLauncher) Output(c Command) (string, error) {
+	if debug {
+		fmt.Printf("🔍 Exec: %s\n", c)
+	}
+
+	cmd := exec.Command(c.Name, c.Args...)
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (execLauncher) Print(t TerminalText) error {
+	if !t.TTY {
+		_, err := fmt.Print(t.Text)
+		return err
+	}
+
+	tty, err := openTTY()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(t.Text)
+	return err
+}
+
+// dryRunLauncher 只打印（或记录）将要执行的命令而不真正启动，用于 --dry-run 和 explain
+// 查找命令仍然使用真实的 PATH，保证解析结果与实际运行时一致
+type dryRunLauncher struct {
+	Record bool           // 只记录不打印
+	Calls  []Command      // 已记录的命令
+	Prints []TerminalText // 已记录的终端输出
+}
+
+func (*dryRunLauncher) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+func (d *dryRunLauncher) Launch(c Command) error {
+	if d.Record {
+		d.Calls = append(d.Calls, c)
+		return nil
+	}
+
+	fmt.Printf("🧪 Would run: %s\n", c)
+	if c.Stdin != "" {
+		fmt.Printf("🧪   with stdin: %q\n", c.Stdin)
+	}
+	return nil
+}
+
+// Output 只读取输出、没有副作用，因此照常执行，保证 dry-run 的结果与实际一致
+func (*dryRunLauncher) Output(c Command) (string, error) {
+	return execLauncher{}.Output(c)
+}
+
+func (d *dryRunLauncher) Print(t TerminalText) error {
+	if d.Record {
+		d.Prints = append(d.Prints, t)
+		return nil
+	}
+
+	if t.TTY {
+		fmt.Printf("🧪 Would write to the terminal: %s\n", t)
+	} else {
+		fmt.Printf("🧪 Would print: %s\n", t)
+	}
+	return nil
+}
+
+// isCommandAvailable 检查命令是否可以在 PATH 中找到
+func isCommandAvailable(name string) bool {
+	_, err := launcher.LookPath(name)
+	return err == nil
+}
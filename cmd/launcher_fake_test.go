@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// FakeLauncher 只记录将要执行的命令而不真正启动任何程序
+// 用于测试中断言具体会启动什么：
+//
+//	fake := &FakeLauncher{Installed: []string{"xdg-open"}}
+//	restore := SetLauncher(fake)
+//	defer restore()
+//	_ = openInFileManager("/tmp")
+//	// fake.Calls[0].Argv() == []string{"xdg-open", "/tmp"}
+type FakeLauncher struct {
+	Installed []string          // LookPath 能找到的命令
+	Errors    map[string]error  // 按命令名返回的错误
+	Outputs   map[string]string // 按命令名返回的标准输出
+	Calls     []Command         // 已记录的命令
+	Printed   []TerminalText    // 已记录的终端输出
+}
+
+func (f *FakeLauncher) LookPath(file string) (string, error) {
+	for _, name := range f.Installed {
+		if name == file {
+			return filepath.Join("/usr/bin", name), nil
+		}
+	}
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
+func (f *FakeLauncher) Launch(c Command) error {
+	f.Calls = append(f.Calls, c)
+	return f.Errors[c.Name]
+}
+
+func (f *FakeLauncher) Output(c Command) (string, error) {
+	f.Calls = append(f.Calls, c)
+	return f.Outputs[c.Name], f.Errors[c.Name]
+}
+
+func (f *FakeLauncher) Print(t TerminalText) error {
+	f.Printed = append(f.Printed, t)
+	return nil
+}
+
+// LastCall 返回最后一次记录的命令
+func (f *FakeLauncher) LastCall() (Command, bool) {
+	if len(f.Calls) == 0 {
+		return Command{}, false
+	}
+	return f.Calls[len(f.Calls)-1], true
+}
+
+// Reset 清空已记录的命令
+func (f *FakeLauncher) Reset() {
+	f.Calls = nil
+	f.Printed = nil
+}
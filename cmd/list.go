@@ -2,34 +2,47 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var listByScore bool
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "show recent paths",
-	Long:  "Display recently opened paths",
+	Long:  "Display recently opened paths (use --score to rank them by frecency)",
 	Run: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 
-		if len(config.RecentPaths) == 0 {
+		entries := recentHistory()
+		if listByScore {
+			entries = rankedHistory()
+		}
+
+		if len(entries) == 0 {
 			fmt.Println("📝 No recent paths found")
 			return
 		}
 
+		if config.MaxRecent > 0 && len(entries) > config.MaxRecent {
+			entries = entries[:config.MaxRecent]
+		}
+
 		fmt.Println("📝 Recent paths:")
-		for i, path := range config.RecentPaths {
-			if isPathValid(path) {
-				fmt.Printf("  %d. %s\n", i+1, formatPath(path))
+		now := time.Now()
+		for i, entry := range entries {
+			if listByScore {
+				fmt.Printf("  %d. %6.1f  %s\n", i+1, entry.frecency(now), formatPath(entry.Path))
 			} else {
-				// 移除无效路径
-				config.RecentPaths = append(config.RecentPaths[:i], config.RecentPaths[i+1:]...)
+				fmt.Printf("  %d. %s\n", i+1, formatPath(entry.Path))
 			}
 		}
 	},
 }
 
 func init() {
+	listCmd.Flags().BoolVarP(&listByScore, "score", "s", false, "rank by frecency and show scores")
 	rootCmd.AddCommand(listCmd)
 }
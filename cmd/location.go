@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 路径后面可以带上位置信息，编译器、grep 和堆栈信息中常见的格式都可以直接使用：
+//
+//	cmd/root.go:245       行号
+//	cmd/root.go:245:3     行号和列号（允许末尾多一个冒号，例如 grep -n 的输出）
+//	cmd/root.go#L245      GitHub 风格
+//	cmd/root.go#L245C3    GitHub 风格，带列号
+//	cmd/root.go#L245-L250 GitHub 风格的范围，只使用起始行
+
+var (
+	colonLocationPattern  = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?:?$`)
+	githubLocationPattern = regexp.MustCompile(`^(.+)#L(\d+)(?:C(\d+))?(?:-L?\d+(?:C\d+)?)?$`)
+)
+
+// knownTerminalEditors 只能在终端中运行的编辑器，启动时连接到当前终端而不是在后台运行
+// （emacs 有图形界面时会打开窗口，不在此列）
+var knownTerminalEditors = []string{"vim", "nvim", "vi", "nano", "micro", "hx", "helix", "kak", "joe", "ne", "mg"}
+
+// isTerminalEditor 判断命令是否为终端编辑器
+func isTerminalEditor(program string) bool {
+	return containsString(knownTerminalEditors, editorProgramName(program))
+}
+
+// defaultEditorArgs 常见编辑器跳转到指定位置的参数约定
+// 键为可执行程序名称，值为参数模板（不含程序本身）
+var defaultEditorArgs = map[string]string{
+	// VS Code 及其衍生版本
+	"code":          "--goto {path}:{line}:{col}",
+	"code-insiders": "--goto {path}:{line}:{col}",
+	"codium":        "--goto {path}:{line}:{col}",
+	"cursor":        "--goto {path}:{line}:{col}",
+	// 终端编辑器
+	"vim":         "+{line} {path}",
+	"nvim":        "+{line} {path}",
+	"vi":          "+{line} {path}",
+	"nano":        "+{line},{col} {path}",
+	"emacs":       "+{line}:{col} {path}",
+	"emacsclient": "+{line}:{col} {path}",
+	"micro":       "{path}:{line}:{col}",
+	"hx":          "{path}:{line}:{col}",
+	// Sublime Text / Zed
+	"subl":         "{path}:{line}:{col}",
+	"sublime_text": "{path}:{line}:{col}",
+	"zed":          "{path}:{line}:{col}",
+	"mate":         "-l {line}:{col} {path}",
+	// JetBrains IDE
+	"idea":      "--line {line} --column {col} {path}",
+	"goland":    "--line {line} --column {col} {path}",
+	"pycharm":   "--line {line} --column {col} {path}",
+	"webstorm":  "--line {line} --column {col} {path}",
+	"phpstorm":  "--line {line} --column {col} {path}",
+	"clion":     "--line {line} --column {col} {path}",
+	"rubymine":  "--line {line} --column {col} {path}",
+	"rider":     "--line {line} --column {col} {path}",
+	"studio":    "--line {line} --column {col} {path}",
+	"fleet":     "--line {line} --column {col} {path}",
+	"datagrip":  "--line {line} --column {col} {path}",
+	"rustrover": "--line {line} --column {col} {path}",
+}
+
+// parseLocation 拆分路径末尾的 :line[:col] 或 #L42 后缀
+// 路径本身存在时（文件名中可能真的包含冒号）原样返回
+func parseLocation(arg string) templateContext {
+	if isPathValid(arg) {
+		return templateContext{Path: arg}
+	}
+
+	for _, pattern := range []*regexp.Regexp{githubLocationPattern, colonLocationPattern} {
+		m := pattern.FindStringSubmatch(arg)
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		return templateContext{Path: m[1], Line: line, Col: col}
+	}
+
+	return templateContext{Path: arg}
+}
+
+// formatLocation 返回带位置信息的路径，用于输出
+func formatLocation(t templateContext) string {
+	s := formatPath(t.Path)
+	if t.Line > 0 {
+		s += ":" + strconv.Itoa(t.Line)
+		if t.Col > 0 {
+			s += ":" + strconv.Itoa(t.Col)
+		}
+	}
+	return s
+}
+
+// editorProgramName 返回用于查找参数约定的程序名称（去掉目录和 .exe 后缀，小写）
+func editorProgramName(program string) string {
+	name := strings.ToLower(filepath.Base(program))
+	return strings.TrimSuffix(name, ".exe")
+}
+
+// editorArgs 返回编辑器的位置参数模板，配置中的 editor_args 优先于内置约定
+func editorArgs(program string) (string, bool) {
+	name := editorProgramName(program)
+	if args, exists := config.EditorArgs[name]; exists {
+		return args, args != ""
+	}
+	args, exists := defaultEditorArgs[name]
+	return args, exists
+}
+
+// withEditorArgs 为不含位置占位符的命令模板补上编辑器的位置参数
+// 例如 "code -r" -> "code -r --goto {path}:{line}:{col}"；
+// 模板已经引用了 {line}/{col} 或编辑器没有已知约定时返回 false
+func withEditorArgs(tmpl string) (string, bool) {
+	if strings.Contains(tmpl, "{line}") || strings.Contains(tmpl, "{col}") {
+		return "", false
+	}
+
+	fields, err := splitCommandLine(tmpl)
+	if err != nil || len(fields) == 0 {
+		return "", false
+	}
+
+	args, ok := editorArgs(fields[0])
+	if !ok {
+		return "", false
+	}
+
+	// 去掉原模板中单独的 {path}，由参数约定决定路径的位置
+	kept := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field != "{path}" {
+			kept = append(kept, quoteCommandField(field))
+		}
+	}
+	result := strings.Join(kept, " ") + " " + args
+
+	if debug {
+		fmt.Printf("🔍 Using editor line arguments: %s -> %s\n", tmpl, result)
+	}
+	return result, true
+}
+
+// quoteCommandField 在需要时给参数加上引号，使其能被 splitCommandLine 还原
+func quoteCommandField(field string) string {
+	if field != "" && !strings.ContainsAny(field, " \t\n'\"\\") {
+		return field
+	}
+	return "'" + strings.ReplaceAll(field, "'", `'"'"'`) + "'"
+}
+
+// editorArgNames 返回所有已知编辑器名称（内置约定和配置），按字母排序
+func editorArgNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range []map[string]string{defaultEditorArgs, config.EditorArgs} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
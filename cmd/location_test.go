@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	dir := t.TempDir()
+	// 文件名本身包含冒号和行号时原样使用
+	existing := filepath.Join(dir, "notes:12")
+	if err := os.WriteFile(existing, nil, 0600); err != nil {
+		t.Skipf("cannot create a file name with a colon: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		arg  string
+		want templateContext
+	}{
+		{"plain path", "cmd/root.go", templateContext{Path: "cmd/root.go"}},
+		{"line", "cmd/root.go:245", templateContext{Path: "cmd/root.go", Line: 245}},
+		{"line and col", "cmd/root.go:245:3", templateContext{Path: "cmd/root.go", Line: 245, Col: 3}},
+		{"grep trailing colon", "cmd/root.go:245:", templateContext{Path: "cmd/root.go", Line: 245}},
+		{"github line", "cmd/root.go#L245", templateContext{Path: "cmd/root.go", Line: 245}},
+		{"github line and col", "cmd/root.go#L245C3", templateContext{Path: "cmd/root.go", Line: 245, Col: 3}},
+		{"github range", "cmd/root.go#L245-L250", templateContext{Path: "cmd/root.go", Line: 245}},
+		{"github range with col", "cmd/root.go#L245C3-L250C8", templateContext{Path: "cmd/root.go", Line: 245, Col: 3}},
+		{"colon in name", "a:b.txt:7", templateContext{Path: "a:b.txt", Line: 7}},
+		{"not a number", "cmd/root.go:abc", templateContext{Path: "cmd/root.go:abc"}},
+		{"only line", ":12", templateContext{Path: ":12"}},
+		{"existing file with colon", existing, templateContext{Path: existing}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLocation(tt.arg); got != tt.want {
+				t.Errorf("parseLocation(%q) = %+v, want %+v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLocation(t *testing.T) {
+	tests := []struct {
+		in   templateContext
+		want string
+	}{
+		{templateContext{Path: "/tmp/a.go"}, "/tmp/a.go"},
+		{templateContext{Path: "/tmp/a.go", Line: 3}, "/tmp/a.go:3"},
+		{templateContext{Path: "/tmp/a.go", Line: 3, Col: 9}, "/tmp/a.go:3:9"},
+		{templateContext{Path: "/tmp/a.go", Col: 9}, "/tmp/a.go"},
+	}
+
+	for _, tt := range tests {
+		if got := formatLocation(tt.in); got != tt.want {
+			t.Errorf("formatLocation(%+v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package cmd
+
+import "os"
+
+// lockFile 当前平台不支持文件锁，直接返回
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile 当前平台不支持文件锁，直接返回
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// managerConfig custom_managers 中的一项
+// 可以直接写命令模板（"code {path}"），也可以写成带选项的映射：
+//
+//	custom_managers:
+//	  vscode: "code --reuse-window {path}"
+//	  yazi:
+//	    command: "yazi {path}"
+//	    terminal: true
+type managerConfig struct {
+	Command  string `mapstructure:"command"`
+	Terminal bool   `mapstructure:"terminal"` // 终端文件管理器：连接到当前终端运行
+}
+
+// knownTerminalManagers 常见的终端文件管理器，按自动选择时的优先顺序排列
+var knownTerminalManagers = []string{"yazi", "lf", "ranger", "nnn", "vifm", "joshuto", "xplr", "broot", "mc"}
+
+// managerConfigHook 让 custom_managers 的值既可以是字符串也可以是映射
+func managerConfigHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != reflect.TypeOf(managerConfig{}) || from.Kind() != reflect.String {
+		return data, nil
+	}
+	return managerConfig{Command: data.(string)}, nil
+}
+
+// managerConfigValues 返回用于写回配置文件的 custom_managers
+// 普通管理器仍然保存为字符串，保持配置文件简洁
+func managerConfigValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(config.CustomManagers))
+	for name, m := range config.CustomManagers {
+		if m.Terminal {
+			values[name] = map[string]interface{}{"command": m.Command, "terminal": true}
+		} else {
+			values[name] = m.Command
+		}
+	}
+	return values
+}
+
+// isTerminalManager 判断管理器是否需要连接到当前终端运行
+// 配置中的 terminal 选项优先，其次按内置的终端文件管理器列表判断
+func isTerminalManager(name string) bool {
+	if m, exists := config.CustomManagers[name]; exists {
+		return m.Terminal
+	}
+	return containsString(knownTerminalManagers, editorProgramName(commandProgram(name)))
+}
+
+// hasGraphicalSession 判断当前是否有图形界面
+// macOS 和 Windows 总是认为有，其他平台检查 DISPLAY 和 WAYLAND_DISPLAY
+func hasGraphicalSession() bool {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// pickTerminalManager 选择可用的终端文件管理器
+// 顺序：terminal_manager 配置 -> custom_managers 中标记为 terminal 的管理器 -> 内置列表中 PATH 里能找到的
+func pickTerminalManager() string {
+	if config.TerminalManager != "" {
+		return config.TerminalManager
+	}
+
+	names := make([]string, 0, len(config.CustomManagers))
+	for name, m := range config.CustomManagers {
+		if m.Terminal {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		return names[0]
+	}
+
+	for _, name := range knownTerminalManagers {
+		if isCommandAvailable(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// resolveAutoManager 处理 auto 管理器：有图形界面时使用平台默认方式，否则使用终端文件管理器
+func resolveAutoManager() {
+	if manager != "auto" {
+		return
+	}
+
+	if hasGraphicalSession() {
+		manager = ""
+		if debug {
+			fmt.Printf("🔍 Auto manager: graphical session found, using platform opener\n")
+		}
+		return
+	}
+
+	manager = pickTerminalManager()
+	if debug {
+		if manager != "" {
+			fmt.Printf("🔍 Auto manager: no graphical session, using terminal manager: %s\n", manager)
+		} else {
+			fmt.Printf("🔍 Auto manager: no graphical session and no terminal manager found\n")
+		}
+	}
+}
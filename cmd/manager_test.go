@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// useManagerConfig 替换测试期间使用的管理器相关配置
+func useManagerConfig(t *testing.T, managers map[string]managerConfig, terminalManager string) {
+	t.Helper()
+	savedManagers, savedTerminal, savedManager := config.CustomManagers, config.TerminalManager, manager
+	config.CustomManagers, config.TerminalManager = managers, terminalManager
+	t.Cleanup(func() {
+		config.CustomManagers, config.TerminalManager, manager = savedManagers, savedTerminal, savedManager
+	})
+}
+
+func TestIsTerminalManager(t *testing.T) {
+	useManagerConfig(t, map[string]managerConfig{
+		"files": {Command: "nautilus {path}"},
+		"tui":   {Command: "my-tui {path}", Terminal: true},
+		"lf":    {Command: "lf {path}"}, // 配置优先于内置列表
+	}, "")
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"files", false},
+		{"tui", true},
+		{"lf", false},
+		{"yazi", true},
+		{"/usr/local/bin/ranger", true},
+		{"nnn -d {path}", true},
+		{"nautilus", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTerminalManager(tt.name); got != tt.want {
+			t.Errorf("isTerminalManager(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAutoManager(t *testing.T) {
+	tests := []struct {
+		name            string
+		display         string
+		managers        map[string]managerConfig
+		terminalManager string
+		installed       []string
+		want            string
+	}{
+		{name: "graphical session", display: ":0", installed: []string{"yazi"}, want: ""},
+		{name: "terminal_manager config", terminalManager: "vifm", installed: []string{"yazi"}, want: "vifm"},
+		{
+			name: "custom terminal manager",
+			managers: map[string]managerConfig{
+				"zz":    {Command: "zz {path}", Terminal: true},
+				"aa":    {Command: "aa {path}", Terminal: true},
+				"files": {Command: "nautilus {path}"},
+			},
+			installed: []string{"yazi"},
+			want:      "aa",
+		},
+		{name: "first installed", installed: []string{"nnn", "ranger"}, want: "ranger"},
+		{name: "nothing installed", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.display == "" && runtime.GOOS != "linux" {
+				t.Skip("macOS and Windows always have a graphical session")
+			}
+			t.Setenv("DISPLAY", tt.display)
+			t.Setenv("WAYLAND_DISPLAY", "")
+			useManagerConfig(t, tt.managers, tt.terminalManager)
+			t.Cleanup(SetLauncher(&FakeLauncher{Installed: tt.installed}))
+
+			manager = "auto"
+			resolveAutoManager()
+			if manager != tt.want {
+				t.Errorf("resolveAutoManager() = %q, want %q", manager, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAutoManagerKeepsOtherManagers(t *testing.T) {
+	useManagerConfig(t, nil, "")
+	manager = "yazi"
+	resolveAutoManager()
+	if manager != "yazi" {
+		t.Errorf("resolveAutoManager() changed manager to %q", manager)
+	}
+}
+
+func TestLaunchTemplateTerminal(t *testing.T) {
+	tests := []struct {
+		terminal bool
+		want     Command
+	}{
+		{terminal: true, want: Command{Name: "yazi", Args: []string{"/tmp"}, Terminal: true}},
+		{terminal: false, want: Command{Name: "nautilus", Args: []string{"/tmp"}, Detach: true}},
+	}
+
+	for _, tt := range tests {
+		fake := &FakeLauncher{}
+		restore := SetLauncher(fake)
+		name := tt.want.Name
+		if err := launchTemplate(name, templateContext{Path: "/tmp"}, tt.terminal); err != nil {
+			t.Fatalf("launchTemplate(%q): %v", name, err)
+		}
+		restore()
+		if got, _ := fake.LastCall(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("launchTemplate(%q, terminal=%v) = %+v, want %+v", name, tt.terminal, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAppCommandsTerminal(t *testing.T) {
+	useManagerConfig(t, map[string]managerConfig{
+		"tui": {Command: "my-tui {path}", Terminal: true},
+	}, "")
+	targets := []templateContext{{Path: "/tmp"}}
+
+	tests := []struct {
+		app  string
+		want Command
+	}{
+		{"tui", Command{Name: "my-tui", Args: []string{"/tmp"}, Terminal: true}},
+		{"yazi {path}", Command{Name: "yazi", Args: []string{"/tmp"}, Terminal: true}},
+		{"code {path}", Command{Name: "code", Args: []string{"/tmp"}, Detach: true}},
+	}
+
+	for _, tt := range tests {
+		launches, err := resolveAppCommands(tt.app, targets)
+		if err != nil || len(launches) != 1 {
+			t.Fatalf("resolveAppCommands(%q) = %+v, %v", tt.app, launches, err)
+		}
+		if got := launches[0].Command; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolveAppCommands(%q) = %+v, want %+v", tt.app, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// wellKnownFiles 常见的无扩展名文件
+var wellKnownFiles = map[string]string{
+	"dockerfile":     "text/x-dockerfile",
+	"containerfile":  "text/x-dockerfile",
+	"makefile":       "text/x-makefile",
+	"gnumakefile":    "text/x-makefile",
+	"cmakelists.txt": "text/x-cmake",
+	"jenkinsfile":    "text/x-groovy",
+	"vagrantfile":    "application/x-ruby",
+	"gemfile":        "application/x-ruby",
+	"rakefile":       "application/x-ruby",
+	"procfile":       "text/plain",
+	"license":        "text/plain",
+	"readme":         "text/plain",
+	".bashrc":        "application/x-shellscript",
+	".bash_profile":  "application/x-shellscript",
+	".bash_aliases":  "application/x-shellscript",
+	".profile":       "application/x-shellscript",
+	".zshrc":         "application/x-shellscript",
+	".zprofile":      "application/x-shellscript",
+	".gitignore":     "text/plain",
+	".gitconfig":     "text/plain",
+	".editorconfig":  "text/plain",
+	".env":           "text/plain",
+}
+
+// interpreterTypes shebang 解释器对应的 MIME 类型
+var interpreterTypes = map[string]string{
+	"sh":      "application/x-shellscript",
+	"bash":    "application/x-shellscript",
+	"zsh":     "application/x-shellscript",
+	"dash":    "application/x-shellscript",
+	"ksh":     "application/x-shellscript",
+	"fish":    "application/x-shellscript",
+	"python":  "text/x-python",
+	"node":    "application/javascript",
+	"deno":    "application/javascript",
+	"ruby":    "application/x-ruby",
+	"perl":    "application/x-perl",
+	"php":     "application/x-php",
+	"lua":     "text/x-lua",
+	"awk":     "application/x-awk",
+	"tclsh":   "text/x-tcl",
+	"Rscript": "text/x-r",
+}
+
+// magicSignatures http.DetectContentType 未覆盖的文件头
+var magicSignatures = []struct {
+	prefix []byte
+	mime   string
+}{
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{[]byte("\xfe\xed\xfa\xce"), "application/x-mach-binary"},
+	{[]byte("\xfe\xed\xfa\xcf"), "application/x-mach-binary"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{[]byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{[]byte("BZh"), "application/x-bzip2"},
+	{[]byte("fLaC"), "audio/flac"},
+	{[]byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+}
+
+// detectMIME 检测文件的 MIME 类型
+// 依次使用：常见文件名、shebang 与文件头、shared-mime-info globs、扩展名表，
+// 最后根据内容判断是文本还是二进制
+func detectMIME(path string) string {
+	name := filepath.Base(path)
+	if mimeType, exists := wellKnownFiles[strings.ToLower(name)]; exists {
+		return mimeType
+	}
+
+	head := readFileHead(path, 512)
+
+	// shebang 和二进制文件头优先于扩展名，以识别改错扩展名的文件
+	if mimeType := sniffInterpreter(head); mimeType != "" {
+		return mimeType
+	}
+	sniffed := sniffContent(head)
+	if sniffed != "" && !strings.HasPrefix(sniffed, "text/") && sniffed != "application/octet-stream" {
+		return sniffed
+	}
+
+	if mimeType := sharedMimeGlobs().match(name); mimeType != "" {
+		return mimeType
+	}
+	if ext := filepath.Ext(name); ext != "" {
+		if mimeType := mime.TypeByExtension(strings.ToLower(ext)); mimeType != "" {
+			return stripMIMEParams(mimeType)
+		}
+	}
+
+	if sniffed != "" {
+		return sniffed
+	}
+	return "application/octet-stream"
+}
+
+// readFileHead 读取文件开头的若干字节
+func readFileHead(path string, n int) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil
+	}
+	return buf[:read]
+}
+
+// sniffInterpreter 根据 shebang 行识别脚本类型
+func sniffInterpreter(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+
+	line := string(head[2:])
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	// #!/usr/bin/env [-S] python3
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// python3.11 -> python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	if mimeType, exists := interpreterTypes[interpreter]; exists {
+		return mimeType
+	}
+	if interpreter != "" {
+		return "text/x-script." + interpreter
+	}
+	return ""
+}
+
+// sniffContent 根据文件头识别类型
+func sniffContent(head []byte) string {
+	if len(head) == 0 {
+		return ""
+	}
+	for _, sig := range magicSignatures {
+		if bytes.HasPrefix(head, sig.prefix) {
+			return sig.mime
+		}
+	}
+	return stripMIMEParams(http.DetectContentType(head))
+}
+
+// stripMIMEParams 去掉 MIME 类型中的参数，例如 "; charset=utf-8"
+func stripMIMEParams(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// isMIMEKey 判断 file_type_apps 中的键是否为 MIME 类型（包括 text/* 这样的通配符）
+func isMIMEKey(key string) bool {
+	return strings.Contains(key, "/")
+}
+
+// matchMIME 判断 MIME 类型是否匹配配置中的模式，支持 "text/*" 和 "*/*"
+func matchMIME(pattern string, mimeType string) bool {
+	if pattern == mimeType || pattern == "*/*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// formatFileTypeKey 格式化 file_type_apps 的键用于显示
+func formatFileTypeKey(key string) string {
+	if isMIMEKey(key) || isFileGroupKey(key) {
+		return key
+	}
+	return "." + key
+}
+
+// mimeGlob shared-mime-info globs2 中的一条规则
+type mimeGlob struct {
+	weight        int
+	mime          string
+	pattern       string
+	caseSensitive bool
+}
+
+// mimeGlobs 按权重匹配文件名的规则集合
+type mimeGlobs []mimeGlob
+
+var (
+	globsOnce   sync.Once
+	globsLoaded mimeGlobs
+)
+
+// sharedMimeGlobs 加载 shared-mime-info 的 globs2 数据库（只加载一次）
+func sharedMimeGlobs() mimeGlobs {
+	globsOnce.Do(func() {
+		for _, dir := range xdgDataDirs() {
+			globsLoaded = append(globsLoaded, loadGlobs2(filepath.Join(dir, "mime", "globs2"))...)
+		}
+		if debug && len(globsLoaded) > 0 {
+			fmt.Printf("🔍 Loaded %d shared-mime-info globs\n", len(globsLoaded))
+		}
+	})
+	return globsLoaded
+}
+
+// xdgDataDirs 返回 XDG 数据目录（用户目录优先）
+func xdgDataDirs() []string {
+	var dirs []string
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dataHome != "" {
+		dirs = append(dirs, dataHome)
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// loadGlobs2 解析 globs2 文件，每行格式为 weight:mime:glob[:flags]
+func loadGlobs2(file string) mimeGlobs {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var globs mimeGlobs
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 4)
+		if len(parts) < 3 {
+			continue
+		}
+		weight, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		glob := mimeGlob{weight: weight, mime: parts[1], pattern: parts[2]}
+		if len(parts) == 4 && strings.Contains(parts[3], "cs") {
+			glob.caseSensitive = true
+		}
+		if !glob.caseSensitive {
+			glob.pattern = strings.ToLower(glob.pattern)
+		}
+		globs = append(globs, glob)
+	}
+	return globs
+}
+
+// match 返回权重最高（同权重时模式最长，再相同时区分大小写的模式优先）的匹配结果
+func (g mimeGlobs) match(name string) string {
+	lower := strings.ToLower(name)
+
+	best := -1
+	for i, glob := range g {
+		candidate := lower
+		if glob.caseSensitive {
+			candidate = name
+		}
+		if ok, _ := filepath.Match(glob.pattern, candidate); !ok {
+			continue
+		}
+		if best < 0 || glob.weight > g[best].weight ||
+			(glob.weight == g[best].weight && len(glob.pattern) > len(g[best].pattern)) ||
+			(glob.weight == g[best].weight && len(glob.pattern) == len(g[best].pattern) && glob.caseSensitive && !g[best].caseSensitive) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		return ""
+	}
+	return g[best].mime
+}
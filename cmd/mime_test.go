@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testGlobs2 shared-mime-info globs2 格式的测试数据
+const testGlobs2 = `# This file was automatically generated by the
+# update-mime-database application.
+50:text/x-csrc:*.c
+50:text/x-c++src:*.C:cs
+50:application/x-compressed-tar:*.tar.gz
+50:application/gzip:*.gz
+60:text/x-makefile:makefile
+10:text/x-readme:readme*
+invalid line
+x:text/plain:*.txt
+50:text/x-go
+
+50:text/x-go:*.go
+`
+
+func TestLoadGlobs2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "globs2")
+	if err := os.WriteFile(path, []byte(testGlobs2), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got := loadGlobs2(path)
+	want := mimeGlobs{
+		{weight: 50, mime: "text/x-csrc", pattern: "*.c"},
+		{weight: 50, mime: "text/x-c++src", pattern: "*.C", caseSensitive: true},
+		{weight: 50, mime: "application/x-compressed-tar", pattern: "*.tar.gz"},
+		{weight: 50, mime: "application/gzip", pattern: "*.gz"},
+		{weight: 60, mime: "text/x-makefile", pattern: "makefile"},
+		{weight: 10, mime: "text/x-readme", pattern: "readme*"},
+		{weight: 50, mime: "text/x-go", pattern: "*.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadGlobs2 =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if globs := loadGlobs2(filepath.Join(t.TempDir(), "missing")); globs != nil {
+		t.Errorf("loadGlobs2(missing) = %+v, want nil", globs)
+	}
+}
+
+func TestMimeGlobsMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "globs2")
+	if err := os.WriteFile(path, []byte(testGlobs2), 0600); err != nil {
+		t.Fatal(err)
+	}
+	globs := loadGlobs2(path)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"main.c", "text/x-csrc"},
+		{"MAIN.c", "text/x-csrc"},
+		{"main.C", "text/x-c++src"},
+		{"main.go", "text/x-go"},
+		{"MAIN.GO", "text/x-go"},
+		{"backup.tar.gz", "application/x-compressed-tar"},
+		{"notes.gz", "application/gzip"},
+		{"Makefile", "text/x-makefile"},
+		{"README.md", "text/x-readme"},
+		{"notes.txt", ""},
+		{"noext", ""},
+	}
+
+	for _, tt := range tests {
+		if got := globs.match(tt.name); got != tt.want {
+			t.Errorf("match(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// openResult 一个路径的打开结果
+type openResult struct {
+	Target templateContext
+	App    string // 使用的应用程序或文件管理器名称
+	Err    error
+}
+
+// resolvePathArgs 展开路径参数：@书签、通配符、:line:col 位置后缀，并转换为去重后的绝对路径
+// URL 在路径校验之前识别：file:// 解码为本地路径，其他 URL 原样保留（Path 与 URL 相同）
+// 无法解析的参数作为错误返回，错误信息中包含参数本身
+func resolvePathArgs(args []string) ([]templateContext, []error) {
+	var (
+		targets  []templateContext
+		failures []error
+		seen     = map[string]bool{}
+	)
+
+	add := func(t templateContext) {
+		absPath, err := filepath.Abs(t.Path)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("cannot get absolute path: %s: %v", t.Path, err))
+			return
+		}
+		if !seen[absPath] {
+			seen[absPath] = true
+			t.Path = absPath
+			targets = append(targets, t)
+		}
+	}
+
+	for _, arg := range args {
+		switch scheme := uriScheme(arg); scheme {
+		case "":
+		case "file":
+			local, err := fileURIToPath(arg)
+			if err != nil {
+				failures = append(failures, err)
+				continue
+			}
+			arg = local
+		default:
+			if !seen[arg] {
+				seen[arg] = true
+				targets = append(targets, templateContext{Path: arg, URL: arg})
+			}
+			continue
+		}
+
+		expanded := expandBookmark(arg)
+
+		// 历史版本：--rev 或 file@REV（文件在工作区中可以已被删除）
+		if loc := parseLocation(expanded); revision != "" || !isPathValid(loc.Path) {
+			p, rev := loc.Path, revision
+			if rev == "" {
+				p, rev = splitRevSpec(p)
+			}
+			if rev != "" {
+				local, err := checkoutRevision(p, rev)
+				if err != nil {
+					failures = append(failures, fmt.Errorf("cannot read %s at %s: %v", p, rev, err))
+					continue
+				}
+				loc.Path = local
+				add(loc)
+				continue
+			}
+		}
+
+		if loc := parseLocation(expanded); isPathValid(loc.Path) {
+			if !isFile(loc.Path) {
+				loc.Line, loc.Col = 0, 0 // 文件夹没有行号
+			}
+			add(loc)
+			continue
+		}
+
+		// 没有被 shell 展开的通配符（例如带引号或在 Windows 上）
+		if hasGlobMeta(expanded) {
+			matches, err := filepath.Glob(expanded)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("invalid pattern: %s: %v", arg, err))
+				continue
+			}
+			if len(matches) == 0 {
+				failures = append(failures, fmt.Errorf("no matches for pattern: %s", arg))
+				continue
+			}
+			for _, match := range matches {
+				add(templateContext{Path: match})
+			}
+			continue
+		}
+
+		failures = append(failures, fmt.Errorf("path does not exist: %s", expanded))
+	}
+
+	return targets, failures
+}
+
+// hasGlobMeta 判断字符串是否包含通配符
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// openPaths 打开多个路径
+// 文件和命中 directory_rules 的文件夹按解析出的应用分组，每个应用只启动一次（应用支持多个参数时）；
+// 其他文件夹和没有配置应用的文件逐个交给文件管理器
+func openPaths(targets []templateContext) []openResult {
+	results := make(map[string]openResult, len(targets))
+
+	var apps []string
+	groups := map[string][]templateContext{}
+	var others []templateContext
+
+	for _, t := range targets {
+		if t.URL != "" {
+			continue
+		}
+		if app := getAppForPath(t.Path); app != "" {
+			if _, exists := groups[app]; !exists {
+				apps = append(apps, app)
+			}
+			groups[app] = append(groups[app], t)
+			continue
+		}
+		if t.Line > 0 && debug {
+			fmt.Printf("🔍 No app configured for %s, ignoring line number\n", t.Path)
+		}
+		others = append(others, t)
+	}
+
+	for _, app := range apps {
+		if debug {
+			fmt.Printf("🔍 File type detected, using app: %s (%d files)\n", app, len(groups[app]))
+		}
+		errs := openFilesWithApp(groups[app], app)
+		for _, t := range groups[app] {
+			results[t.Path] = openResult{Target: t, App: app, Err: errs[t.Path]}
+		}
+	}
+
+	managerName := fileManagerLabel()
+	for _, t := range others {
+		results[t.Path] = openResult{Target: t, App: managerName, Err: openInFileManager(t.Path)}
+	}
+
+	for _, t := range targets {
+		if t.URL != "" {
+			app, err := openURL(t)
+			results[t.Path] = openResult{Target: t, App: app, Err: err}
+		}
+	}
+
+	// 按输入顺序返回
+	ordered := make([]openResult, 0, len(targets))
+	for _, t := range targets {
+		ordered = append(ordered, results[t.Path])
+	}
+	return ordered
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Opener 表示一种“用系统默认方式打开路径”的后端，例如 xdg-open、gio、open、explorer
+type Opener interface {
+	// Name 返回后端的注册名称，用于配置中的 opener 字段
+	Name() string
+	// Available 判断当前系统上是否可以使用该后端
+	Available() bool
+	// OpenCommand 返回用默认程序打开 path 的命令
+	OpenCommand(path string) Command
+	// OpenWithCommand 返回用指定应用打开 paths 的命令，不支持时第二个返回值为 false
+	// 后端不能一次接收多个文件时，每个文件对应一条命令
+	OpenWithCommand(app string, paths []string) ([]appLaunch, bool)
+	// RevealCommand 返回在父目录中选中 path 的命令，不支持时第二个返回值为 false
+	RevealCommand(path string) (Command, bool)
+	// CanWait 判断打开命令能否等到应用退出后再返回（--wait）
+	CanWait() bool
+	// CanOpenWith 判断能否用指定应用打开（OpenWithCommand）
+	CanOpenWith() bool
+}
+
+// commandOpener 基于单个可执行文件的通用 Opener 实现
+type commandOpener struct {
+	name       string
+	platforms  []string                                  // 支持的 GOOS，为空表示全部
+	bin        string                                    // 可执行文件
+	args       []string                                  // 位于路径之前的固定参数
+	withApp    func(app string, paths []string) []string // 指定应用打开时的参数，nil 表示不支持
+	multi      bool                                      // withApp 是否支持一次传入多个文件
+	reveal     func(path string) []string                // 选中文件时的参数，nil 表示不支持
+	wait       func(args []string) []string              // --wait 时改写打开参数，nil 表示无法等待
+	ignoreExit bool
+}
+
+func (o commandOpener) Name() string {
+	return o.name
+}
+
+func (o commandOpener) Available() bool {
+	if len(o.platforms) > 0 && !containsString(o.platforms, runtime.GOOS) {
+		return false
+	}
+	return isCommandAvailable(o.bin)
+}
+
+func (o commandOpener) OpenCommand(path string) Command {
+	args := append(append([]string{}, o.args...), path)
+	return Command{Name: o.bin, Args: o.waitArgs(args), IgnoreExit: o.ignoreExit}
+}
+
+func (o commandOpener) CanWait() bool {
+	return o.wait != nil
+}
+
+func (o commandOpener) CanOpenWith() bool {
+	return o.withApp != nil
+}
+
+// waitArgs --wait 时返回等待应用退出的参数
+func (o commandOpener) waitArgs(args []string) []string {
+	if waitForExit && o.wait != nil {
+		return o.wait(args)
+	}
+	return args
+}
+
+func (o commandOpener) OpenWithCommand(app string, paths []string) ([]appLaunch, bool) {
+	if o.withApp == nil {
+		return nil, false
+	}
+
+	if o.multi {
+		cmd := Command{Name: o.bin, Args: o.waitArgs(o.withApp(app, paths)), IgnoreExit: o.ignoreExit}
+		return []appLaunch{{Command: cmd, Paths: paths}}, true
+	}
+
+	launches := make([]appLaunch, 0, len(paths))
+	for _, path := range paths {
+		cmd := Command{Name: o.bin, Args: o.waitArgs(o.withApp(app, []string{path})), IgnoreExit: o.ignoreExit}
+		launches = append(launches, appLaunch{Command: cmd, Paths: []string{path}})
+	}
+	return launches, true
+}
+
+func (o commandOpener) RevealCommand(path string) (Command, bool) {
+	if o.reveal == nil {
+		return Command{}, false
+	}
+	return Command{Name: o.bin, Args: o.reveal(path), IgnoreExit: o.ignoreExit}, true
+}
+
+// openers 已注册的 Opener，按名称索引
+var openers = map[string]Opener{}
+
+// registerOpener 注册一个 Opener
+func registerOpener(o Opener) {
+	openers[o.Name()] = o
+}
+
+func init() {
+	unixLike := []string{"linux", "freebsd", "openbsd", "netbsd", "dragonfly"}
+
+	registerOpener(commandOpener{name: "xdg-open", platforms: unixLike, bin: "xdg-open"})
+	registerOpener(commandOpener{name: "gio", platforms: unixLike, bin: "gio", args: []string{"open"}})
+	registerOpener(commandOpener{name: "kioclient5", platforms: unixLike, bin: "kioclient5", args: []string{"exec"}})
+	registerOpener(commandOpener{name: "kioclient", platforms: unixLike, bin: "kioclient", args: []string{"exec"}})
+	registerOpener(commandOpener{name: "exo-open", platforms: unixLike, bin: "exo-open"})
+	registerOpener(commandOpener{
+		name:      "open",
+		platforms: []string{"darwin"},
+		bin:       "open",
+		withApp: func(app string, paths []string) []string {
+			return append([]string{"-a", app}, paths...)
+		},
+		multi: true,
+		reveal: func(path string) []string {
+			return []string{"-R", path}
+		},
+		wait: func(args []string) []string {
+			return append([]string{"-W"}, args...)
+		},
+	})
+	registerOpener(commandOpener{
+		name:       "explorer",
+		platforms:  []string{"windows"},
+		bin:        "explorer",
+		ignoreExit: true, // Windows explorer 即使成功打开文件夹也可能返回非零状态码
+		reveal: func(path string) []string {
+			return []string{"/select," + path}
+		},
+	})
+	registerOpener(commandOpener{
+		name:      "start",
+		platforms: []string{"windows"},
+		bin:       "cmd",
+		args:      []string{"/c", "start", ""},
+		withApp: func(app string, paths []string) []string {
+			return []string{"/c", "start", "", app, paths[0]}
+		},
+		wait: func(args []string) []string {
+			// cmd /c start "" ... -> cmd /c start /wait "" ...
+			return append([]string{"/c", "start", "/wait"}, args[2:]...)
+		},
+	})
+}
+
+// openerNames 返回所有已注册的 Opener 名称（已排序）
+func openerNames() []string {
+	names := make([]string, 0, len(openers))
+	for name := range openers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// preferredOpeners 返回当前平台自动检测时的优先顺序
+func preferredOpeners() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"explorer", "start"}
+	default:
+		// 根据桌面环境选择最合适的后端，xdg-open 作为通用兜底
+		desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+		switch {
+		case strings.Contains(desktop, "KDE"):
+			return []string{"kioclient5", "kioclient", "xdg-open", "gio"}
+		case strings.Contains(desktop, "XFCE"):
+			return []string{"exo-open", "xdg-open", "gio"}
+		case strings.Contains(desktop, "GNOME"), strings.Contains(desktop, "UNITY"), strings.Contains(desktop, "CINNAMON"):
+			return []string{"gio", "xdg-open"}
+		default:
+			return []string{"xdg-open", "gio", "kioclient5", "kioclient", "exo-open"}
+		}
+	}
+}
+
+// selectOpener 选择要使用的 Opener
+// 优先使用配置中的 opener，否则按平台顺序自动检测
+func selectOpener() (Opener, error) {
+	if name := config.Opener; name != "" {
+		o, exists := openers[name]
+		if !exists {
+			return nil, fmt.Errorf("⚠️ unknown opener: %s (available: %s)", name, strings.Join(openerNames(), ", "))
+		}
+		if !o.Available() {
+			return nil, fmt.Errorf("⚠️ opener %s is not available on this system", name)
+		}
+		if debug {
+			fmt.Printf("🔍 Using configured opener: %s\n", name)
+		}
+		return o, nil
+	}
+
+	for _, name := range preferredOpeners() {
+		if o, exists := openers[name]; exists && o.Available() {
+			if debug {
+				fmt.Printf("🔍 Auto-detected opener: %s\n", name)
+			}
+			return o, nil
+		}
+	}
+
+	return nil, fmt.Errorf("⚠️ no opener available on %s (tried: %s)", runtime.GOOS, strings.Join(preferredOpeners(), ", "))
+}
+
+// selectOpenerFor 选择支持指定操作的 Opener
+// 配置了 opener 时总是使用它；自动检测时跳过不支持该操作的后端，
+// 例如 Windows 上 explorer 不能指定应用也不能等待，这些操作改用 start。
+// 所有后端都不支持时与 selectOpener 相同
+func selectOpenerFor(supports func(Opener) bool) (Opener, error) {
+	if config.Opener == "" {
+		for _, name := range preferredOpeners() {
+			if o, exists := openers[name]; exists && o.Available() && supports(o) {
+				if debug {
+					fmt.Printf("🔍 Auto-detected opener: %s\n", name)
+				}
+				return o, nil
+			}
+		}
+	}
+	return selectOpener()
+}
+
+// canOpenPath 打开路径或 URL：--wait 时需要能够等待应用退出
+func canOpenPath(o Opener) bool {
+	return !waitForExit || o.CanWait()
+}
+
+// canOpenWithApp 用指定应用打开：--wait 时同样需要能够等待
+func canOpenWithApp(o Opener) bool {
+	return o.CanOpenWith() && canOpenPath(o)
+}
+
+// canReveal 在父目录中选中文件
+func canReveal(o Opener) bool {
+	_, ok := o.RevealCommand("")
+	return ok
+}
+
+// checkOpenerWait --wait 时确认平台打开方式能够等待应用退出
+// xdg-open、gio 等把文件交给其他进程后立即返回，无法作为 $EDITOR 使用
+func checkOpenerWait(o Opener) error {
+	if waitForExit && !o.CanWait() {
+		return fmt.Errorf("⚠️ --wait is not supported by opener %s, which returns before the app exits (map the file type to an app, e.g. of config add-filetype md vim)", o.Name())
+	}
+	return nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
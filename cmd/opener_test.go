@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// useFakeLauncher 替换启动器并重置与打开方式相关的全局状态，测试结束后恢复
+func useFakeLauncher(t *testing.T, installed ...string) *FakeLauncher {
+	t.Helper()
+	if !isFreedesktop() {
+		t.Skip("opener tests use the freedesktop openers")
+	}
+
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("XDG_CURRENT_DESKTOP", "")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_DIRS", t.TempDir())
+	// 从 SSH 会话中运行测试时不能触发无图形界面的回退策略
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("SSH_TTY", "")
+
+	savedConfig, savedManager, savedWait := config, manager, waitForExit
+	savedHeadless := config.HeadlessFallback
+	config.Opener = ""
+	config.HeadlessFallback = ""
+	config.CustomManagers = nil
+	manager, waitForExit = "", false
+	t.Cleanup(func() {
+		config, manager, waitForExit = savedConfig, savedManager, savedWait
+		config.HeadlessFallback = savedHeadless
+	})
+
+	fake := &FakeLauncher{Installed: installed}
+	t.Cleanup(SetLauncher(fake))
+	return fake
+}
+
+// replaceOpeners 临时替换已注册的 Opener
+func replaceOpeners(t *testing.T, list ...Opener) {
+	t.Helper()
+	saved := make(map[string]Opener, len(openers))
+	for name, o := range openers {
+		saved[name] = o
+	}
+	for _, o := range list {
+		openers[o.Name()] = o
+	}
+	t.Cleanup(func() { openers = saved })
+}
+
+func TestOpenInFileManager(t *testing.T) {
+	tests := []struct {
+		name      string
+		desktop   string
+		installed []string
+		want      []string
+	}{
+		{"xdg-open first", "", []string{"gio", "xdg-open"}, []string{"xdg-open", "/tmp/x"}},
+		{"gio on GNOME", "GNOME", []string{"gio", "xdg-open"}, []string{"gio", "open", "/tmp/x"}},
+		{"kioclient5 on KDE", "KDE", []string{"kioclient5", "xdg-open"}, []string{"kioclient5", "exec", "/tmp/x"}},
+		{"fallback to gio", "", []string{"gio"}, []string{"gio", "open", "/tmp/x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeLauncher(t, tt.installed...)
+			t.Setenv("XDG_CURRENT_DESKTOP", tt.desktop)
+
+			if err := openInFileManager("/tmp/x"); err != nil {
+				t.Fatalf("openInFileManager: %v", err)
+			}
+			call, ok := fake.LastCall()
+			if !ok || len(fake.Calls) != 1 {
+				t.Fatalf("calls = %v, want one call", fake.Calls)
+			}
+			if got := call.Argv(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("argv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenInFileManagerNoOpener(t *testing.T) {
+	fake := useFakeLauncher(t)
+	if err := openInFileManager("/tmp/x"); err == nil {
+		t.Fatal("expected an error when no opener is installed")
+	}
+	if len(fake.Calls) != 0 {
+		t.Errorf("calls = %v, want none", fake.Calls)
+	}
+}
+
+func TestOpenInFileManagerCustomManager(t *testing.T) {
+	fake := useFakeLauncher(t, "xdg-open", "nautilus")
+	config.CustomManagers = map[string]managerConfig{"files": {Command: "nautilus --new-window {path}"}}
+	manager = "files"
+
+	if err := openInFileManager("/tmp/my dir"); err != nil {
+		t.Fatalf("openInFileManager: %v", err)
+	}
+	call, _ := fake.LastCall()
+	if want := []string{"nautilus", "--new-window", "/tmp/my dir"}; !reflect.DeepEqual(call.Argv(), want) {
+		t.Errorf("argv = %q, want %q", call.Argv(), want)
+	}
+	if !call.Detach || call.Terminal {
+		t.Errorf("Detach = %v, Terminal = %v, want a detached launch", call.Detach, call.Terminal)
+	}
+}
+
+func TestOpenInFileManagerWait(t *testing.T) {
+	fake := useFakeLauncher(t, "xdg-open")
+	waitForExit = true
+
+	if err := openInFileManager("/tmp/x"); err == nil {
+		t.Fatal("expected --wait to be rejected for xdg-open")
+	}
+	if len(fake.Calls) != 0 {
+		t.Errorf("calls = %v, want none", fake.Calls)
+	}
+}
+
+func TestSelectOpenerFor(t *testing.T) {
+	useFakeLauncher(t, "xdg-open", "gio")
+	// xdg-open 排在前面但不能指定应用，模拟 Windows 上 explorer 与 start 的关系
+	replaceOpeners(t,
+		commandOpener{name: "xdg-open", bin: "xdg-open"},
+		commandOpener{
+			name: "gio",
+			bin:  "gio",
+			args: []string{"open"},
+			withApp: func(app string, paths []string) []string {
+				return append([]string{"launch", app}, paths...)
+			},
+			wait: func(args []string) []string { return args },
+		},
+	)
+
+	tests := []struct {
+		name     string
+		opener   string
+		supports func(Opener) bool
+		wait     bool
+		want     string
+	}{
+		{"open path", "", canOpenPath, false, "xdg-open"},
+		{"open path with --wait", "", canOpenPath, true, "gio"},
+		{"open with app", "", canOpenWithApp, false, "gio"},
+		{"nothing can reveal", "", canReveal, false, "xdg-open"},
+		{"configured opener wins", "xdg-open", canOpenWithApp, false, "xdg-open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Opener = tt.opener
+			waitForExit = tt.wait
+			o, err := selectOpenerFor(tt.supports)
+			if err != nil {
+				t.Fatalf("selectOpenerFor: %v", err)
+			}
+			if o.Name() != tt.want {
+				t.Errorf("opener = %s, want %s", o.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAppCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      string
+		targets  []templateContext
+		want     [][]string
+		terminal bool
+	}{
+		{
+			name:    "program in PATH",
+			app:     "code",
+			targets: []templateContext{{Path: "/tmp/a.go"}, {Path: "/tmp/b.go"}},
+			want:    [][]string{{"code", "/tmp/a.go", "/tmp/b.go"}},
+		},
+		{
+			name:    "editor with line",
+			app:     "code",
+			targets: []templateContext{{Path: "/tmp/a.go", Line: 12}},
+			want:    [][]string{{"code", "--goto", "/tmp/a.go:12"}},
+		},
+		{
+			name:     "terminal editor",
+			app:      "vim",
+			targets:  []templateContext{{Path: "/tmp/a.go"}},
+			want:     [][]string{{"vim", "/tmp/a.go"}},
+			terminal: true,
+		},
+		{
+			name:    "unknown app",
+			app:     "missing-app",
+			targets: []templateContext{{Path: "/tmp/a.go"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeLauncher(t, "xdg-open", "code", "vim")
+
+			launches, err := resolveAppCommands(tt.app, tt.targets)
+			if err != nil {
+				t.Fatalf("resolveAppCommands: %v", err)
+			}
+			var got [][]string
+			for _, launch := range launches {
+				got = append(got, launch.Command.Argv())
+				if launch.Command.Terminal != tt.terminal || launch.Command.Detach == tt.terminal {
+					t.Errorf("%s: Terminal = %v, Detach = %v", launch.Command, launch.Command.Terminal, launch.Command.Detach)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("launches = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunLauncher(t *testing.T) {
+	recorder := &dryRunLauncher{Record: true}
+	restore := SetLauncher(recorder)
+	defer restore()
+
+	if err := launcher.Launch(Command{Name: "xdg-open", Args: []string{"/tmp/x"}}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	if err := launcher.Print(TerminalText{Text: "/tmp/x\n"}); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if len(recorder.Calls) != 1 || recorder.Calls[0].String() != "xdg-open /tmp/x" {
+		t.Errorf("calls = %v", recorder.Calls)
+	}
+	if len(recorder.Prints) != 1 {
+		t.Errorf("prints = %v", recorder.Prints)
+	}
+}
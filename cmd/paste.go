@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var pastePrintOnly bool
+
+// embeddedURLPattern 匹配文本中的 scheme://... URL（例如 Markdown 链接或日志中的 URL）
+var embeddedURLPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]+://[^\s<>"'()\[\]{}]+`)
+
+// pasteTrimChars 从单词两端去掉的引号和括号
+const pasteTrimChars = "\"'`<>()[]{},;"
+
+var pasteCmd = &cobra.Command{
+	Use:   "paste",
+	Short: "open the path or URL on the clipboard",
+	Long: `Read the clipboard and open the first path, path:line or URL found in it.
+
+Existing paths win over words that only look like paths, so text copied from
+a chat or log line usually does the right thing. The extracted target goes
+through the same resolution as "of <path>".
+
+Examples:
+  of paste              # e.g. "see /var/log/app.log:120 for details"
+  of paste --print      # only print what would be opened
+  of paste --reveal`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		text, err := readClipboard()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		if debug {
+			fmt.Printf("🔍 Clipboard text: %q\n", text)
+		}
+
+		target, ok := extractOpenTarget(text)
+		if !ok {
+			fmt.Printf("❌ Error: no path or URL found on the clipboard\n")
+			os.Exit(1)
+		}
+		if pastePrintOnly {
+			fmt.Println(target)
+			return
+		}
+		if debug {
+			fmt.Printf("🔍 Extracted from clipboard: %s\n", target)
+		}
+
+		// 与直接运行 of <path> 走相同的流程
+		rootCmd.Run(cmd, []string{target})
+	},
+}
+
+// extractOpenTarget 从文本中提取第一个可以打开的目标
+// 顺序：整段文本本身是路径或 URL -> 第一个 URL 或存在的路径 -> 第一个看起来像路径的单词
+func extractOpenTarget(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", false
+	}
+
+	// 整段文本（可能包含空格的路径）
+	if !strings.ContainsAny(text, "\r\n") {
+		if target, ok := openTarget(strings.Trim(text, pasteTrimChars)); ok {
+			return target, true
+		}
+	}
+
+	fallback := ""
+	for _, word := range strings.Fields(text) {
+		if u := embeddedURLPattern.FindString(word); u != "" {
+			return strings.TrimRight(u, ".,;:!?"), true
+		}
+
+		candidate := strings.Trim(word, pasteTrimChars)
+		candidate = strings.TrimRight(candidate, ".!?")
+		if candidate == "" {
+			continue
+		}
+		if target, ok := openTarget(candidate); ok {
+			return target, true
+		}
+		if fallback == "" && looksLikePath(candidate) {
+			fallback = candidate
+		}
+	}
+
+	if fallback != "" {
+		return expandHome(fallback), true
+	}
+	return "", false
+}
+
+// openTarget 文本是 URI 或存在的路径（可以带 :line 或 #L42）时返回展开 ~ 后的目标
+func openTarget(s string) (string, bool) {
+	if uriScheme(s) != "" {
+		return s, true
+	}
+	s = expandHome(s)
+	return s, isPathValid(parseLocation(s).Path)
+}
+
+// looksLikePath 判断单词是否像一个路径
+func looksLikePath(s string) bool {
+	return strings.HasPrefix(s, "/") || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, "./") ||
+		strings.HasPrefix(s, "../") || strings.HasPrefix(s, `\\`) || windowsDrivePattern.MatchString(s)
+}
+
+// windowsDrivePattern 匹配 Windows 盘符路径，例如 C:\Users 或 C:/Users
+var windowsDrivePattern = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+
+func init() {
+	pasteCmd.Flags().BoolVar(&pastePrintOnly, "print", false, "print the extracted path or URL instead of opening it")
+	pasteCmd.Flags().BoolVarP(&reveal, "reveal", "r", false, "open the parent folder with the path selected")
+	pasteCmd.Flags().StringVarP(&manager, "manager", "m", "", "specify file manager to use")
+	rootCmd.AddCommand(pasteCmd)
+}
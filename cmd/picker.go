@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// pickerAction 选择路径后要执行的操作
+type pickerAction string
+
+const (
+	actionOpen   pickerAction = "open"
+	actionReveal pickerAction = "reveal"
+	actionCopy   pickerAction = "copy"
+	actionRemove pickerAction = "remove"
+)
+
+const (
+	pickerHeight = 10   // 交互模式下最多显示的候选数量
+	pickerPrompt = "❯ " // 提示符
+)
+
+var (
+	interactive bool
+	pickerAct   string
+)
+
+var pickCmd = &cobra.Command{
+	Use:   "pick [query]",
+	Short: "pick a recent path interactively",
+	Long: `Pick a recent path with incremental fuzzy filtering.
+
+Keys:
+  ↑/↓, Ctrl-P/Ctrl-N   move selection
+  Enter                open
+  Ctrl-R               reveal in file manager
+  Ctrl-Y               copy path to clipboard
+  Ctrl-D               remove from history
+  Esc, Ctrl-C          quit
+
+When stdin is not a terminal, the candidates are printed to stderr and a
+line is read from stdin: either a number, or a query whose best match is
+used. Prefix the line with "o", "r", "c" or "d" to choose the action
+(e.g. "r 2"), or set it with --action.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+		runPicker(strings.Join(args, " "))
+	},
+}
+
+// pickerItem 候选项
+type pickerItem struct {
+	entry historyEntry
+	label string // 显示用的路径（~ 缩写）
+	score int
+}
+
+// runPicker 运行选择器并执行所选操作
+func runPicker(query string) {
+	entries := recentHistory()
+	if len(entries) == 0 {
+		fmt.Println("📝 No recent paths found")
+		return
+	}
+
+	items := make([]pickerItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, pickerItem{entry: e, label: formatPath(e.Path)})
+	}
+
+	var (
+		selected *pickerItem
+		action   pickerAction
+		err      error
+	)
+	if isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+		selected, action, err = pickInteractive(items, query)
+	} else {
+		selected, action, err = pickFromReader(items, query, os.Stdin, os.Stderr)
+	}
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if selected == nil {
+		return
+	}
+
+	runPickerAction(action, selected.entry.Path)
+}
+
+// runPickerAction 对选中的路径执行操作
+func runPickerAction(action pickerAction, target string) {
+	switch action {
+	case actionReveal:
+		useDefaultManager()
+		if err := revealPath(target); err != nil {
+			fmt.Printf("❌ Error: cannot reveal path: %v\n", err)
+			os.Exit(1)
+		}
+		addToHistory(target)
+		fmt.Printf("🔎 Revealed in %s: %s\n", fileManagerLabel(), formatPath(target))
+	case actionCopy:
+		if err := copyToClipboardPath(target); err != nil {
+			fmt.Printf("❌ Error: cannot copy path to clipboard: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📋 Path copied to clipboard: %s\n", target)
+	case actionRemove:
+		removeFromHistory(target)
+		fmt.Printf("🗑️ Removed from history: %s\n", formatPath(target))
+	default:
+		useDefaultManager()
+		usedApp, err := openPath(target)
+		if err != nil {
+			fmt.Printf("❌ Error: cannot open path: %v\n", err)
+			os.Exit(1)
+		}
+		addToHistory(target)
+		fmt.Printf("🚀 Opened in %s: %s\n", usedApp, formatPath(target))
+	}
+}
+
+// parsePickerAction 解析 --action 参数或输入行前缀
+func parsePickerAction(s string) (pickerAction, bool) {
+	switch strings.ToLower(s) {
+	case "o", "open":
+		return actionOpen, true
+	case "r", "reveal":
+		return actionReveal, true
+	case "c", "copy":
+		return actionCopy, true
+	case "d", "rm", "remove":
+		return actionRemove, true
+	}
+	return "", false
+}
+
+// filterItems 按模糊匹配得分过滤并排序候选项，得分相同时保持原有顺序（最近优先）
+func filterItems(items []pickerItem, query string) []pickerItem {
+	if query == "" {
+		return append([]pickerItem{}, items...)
+	}
+
+	var matched []pickerItem
+	for _, item := range items {
+		if score, ok := fuzzyScore(item.label, query); ok {
+			item.score = score
+			matched = append(matched, item)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].score > matched[j].score })
+	return matched
+}
+
+// fuzzyScore 计算模糊匹配得分：查询字符需按顺序出现在文本中（不区分大小写）
+// 连续匹配和位于路径分隔符、单词边界之后的匹配得分更高
+func fuzzyScore(text string, query string) (int, bool) {
+	t := []rune(strings.ToLower(text))
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	qi := 0
+	prevMatch := -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		score++
+		if ti == prevMatch+1 {
+			score += 5 // 连续匹配
+		}
+		if ti == 0 || strings.ContainsRune("/\\-_. ", t[ti-1]) {
+			score += 3 // 边界匹配
+		}
+		prevMatch = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+
+	// 匹配位置越靠后（越接近文件名）越好
+	score += prevMatch * 10 / (len(t) + 1)
+	return score, true
+}
+
+// pickFromReader 非终端模式：打印候选列表并从输入读取一行选择
+func pickFromReader(items []pickerItem, query string, in io.Reader, out io.Writer) (*pickerItem, pickerAction, error) {
+	action := actionOpen
+	if pickerAct != "" {
+		a, ok := parsePickerAction(pickerAct)
+		if !ok {
+			return nil, "", fmt.Errorf("unknown action: %s", pickerAct)
+		}
+		action = a
+	}
+
+	filtered := filterItems(items, query)
+	for i, item := range filtered {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, item.label)
+	}
+	fmt.Fprint(out, "Select (number or query): ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, "", nil
+	}
+
+	// 可选的操作前缀，例如 "r 2"
+	if prefix, rest, found := strings.Cut(line, " "); found {
+		if a, ok := parsePickerAction(prefix); ok {
+			action = a
+			line = strings.TrimSpace(rest)
+		}
+	}
+
+	if n, err := strconv.Atoi(line); err == nil {
+		if n < 1 || n > len(filtered) {
+			return nil, "", fmt.Errorf("selection out of range: %d", n)
+		}
+		return &filtered[n-1], action, nil
+	}
+
+	matches := filterItems(filtered, line)
+	if len(matches) == 0 {
+		return nil, "", fmt.Errorf("no recent path matches: %s", line)
+	}
+	return &matches[0], action, nil
+}
+
+// pickInteractive 终端模式：增量模糊过滤与键盘导航
+func pickInteractive(items []pickerItem, query string) (*pickerItem, pickerAction, error) {
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return pickFromReader(items, query, os.Stdin, os.Stderr)
+	}
+
+	ui := &pickerUI{items: items, query: []rune(query), out: os.Stderr}
+	fmt.Fprint(ui.out, "\x1b[?25l") // 隐藏光标
+	defer func() {
+		ui.clear()
+		fmt.Fprint(ui.out, "\x1b[?25h")
+		restore()
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		ui.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch key := string(buf[:n]); key {
+		case "\x03", "\x1b": // Ctrl-C, Esc
+			return nil, "", nil
+		case "\r", "\n":
+			return ui.current(), actionOpen, nil
+		case "\x12": // Ctrl-R
+			return ui.current(), actionReveal, nil
+		case "\x19": // Ctrl-Y
+			return ui.current(), actionCopy, nil
+		case "\x04": // Ctrl-D
+			return ui.current(), actionRemove, nil
+		case "\x1b[A", "\x1bOA", "\x10": // ↑, Ctrl-P
+			ui.move(-1)
+		case "\x1b[B", "\x1bOB", "\x0e": // ↓, Ctrl-N
+			ui.move(1)
+		case "\x7f", "\x08": // Backspace
+			if len(ui.query) > 0 {
+				ui.query = ui.query[:len(ui.query)-1]
+				ui.cursor = 0
+			}
+		case "\x15": // Ctrl-U
+			ui.query = nil
+			ui.cursor = 0
+		default:
+			if strings.HasPrefix(key, "\x1b") {
+				continue // 忽略其他转义序列
+			}
+			for _, r := range key {
+				if unicode.IsPrint(r) {
+					ui.query = append(ui.query, r)
+				}
+			}
+			ui.cursor = 0
+		}
+	}
+}
+
+// pickerUI 交互式选择器的界面状态
+type pickerUI struct {
+	items    []pickerItem
+	query    []rune
+	cursor   int
+	filtered []pickerItem
+	drawn    int // 上一次绘制的行数
+	out      io.Writer
+}
+
+// current 返回当前选中的候选项
+func (u *pickerUI) current() *pickerItem {
+	if u.cursor < 0 || u.cursor >= len(u.filtered) {
+		return nil
+	}
+	item := u.filtered[u.cursor]
+	return &item
+}
+
+// move 移动选中位置
+func (u *pickerUI) move(delta int) {
+	u.cursor += delta
+	if u.cursor < 0 {
+		u.cursor = 0
+	}
+	if u.cursor >= len(u.filtered) {
+		u.cursor = len(u.filtered) - 1
+	}
+}
+
+// clear 清除已绘制的内容
+func (u *pickerUI) clear() {
+	var b strings.Builder
+	b.WriteString("\r\x1b[K")
+	for i := 1; i < u.drawn; i++ {
+		b.WriteString("\x1b[1A\r\x1b[K")
+	}
+	fmt.Fprint(u.out, b.String())
+	u.drawn = 0
+}
+
+// render 重新绘制：提示行、候选列表和预览行
+func (u *pickerUI) render() {
+	u.filtered = filterItems(u.items, string(u.query))
+	if u.cursor >= len(u.filtered) {
+		u.cursor = len(u.filtered) - 1
+	}
+	if u.cursor < 0 {
+		u.cursor = 0
+	}
+
+	// 让选中项始终可见
+	start := 0
+	if u.cursor >= pickerHeight {
+		start = u.cursor - pickerHeight + 1
+	}
+	end := start + pickerHeight
+	if end > len(u.filtered) {
+		end = len(u.filtered)
+	}
+
+	lines := []string{fmt.Sprintf("%s%s  (%d/%d)", pickerPrompt, string(u.query), len(u.filtered), len(u.items))}
+	for i := start; i < end; i++ {
+		if i == u.cursor {
+			lines = append(lines, "\x1b[7m▶ "+u.filtered[i].label+"\x1b[0m")
+		} else {
+			lines = append(lines, "  "+u.filtered[i].label)
+		}
+	}
+	if item := u.current(); item != nil {
+		lines = append(lines, "\x1b[2m"+describePath(item.entry)+"\x1b[0m")
+	} else {
+		lines = append(lines, "\x1b[2mno match\x1b[0m")
+	}
+	lines = append(lines, "\x1b[2menter open · ^R reveal · ^Y copy · ^D remove · esc quit\x1b[0m")
+
+	u.clear()
+	fmt.Fprint(u.out, strings.Join(lines, "\r\n"))
+	u.drawn = len(lines)
+}
+
+// describePath 预览行：类型、大小、修改时间和访问统计
+func describePath(e historyEntry) string {
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return "⚠️ missing: " + e.Path
+	}
+
+	var parts []string
+	if info.IsDir() {
+		count := 0
+		if entries, err := os.ReadDir(e.Path); err == nil {
+			count = len(entries)
+		}
+		parts = append(parts, fmt.Sprintf("📁 directory · %d items", count))
+	} else {
+		parts = append(parts, fmt.Sprintf("📄 %s · %s", detectMIME(e.Path), formatSize(info.Size())))
+	}
+	parts = append(parts, "modified "+info.ModTime().Format("2006-01-02 15:04"))
+	parts = append(parts, fmt.Sprintf("visits %.0f", e.Rank))
+	parts = append(parts, "last "+time.Unix(e.LastAccess, 0).Format("2006-01-02 15:04"))
+	return strings.Join(parts, " · ")
+}
+
+// formatSize 格式化文件大小
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	pickCmd.Flags().StringVarP(&pickerAct, "action", "a", "open", "action when not on a TTY: open, reveal, copy or remove")
+	rootCmd.AddCommand(pickCmd)
+}
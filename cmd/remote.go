@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 反向打开：在本地桌面运行 of serve，远程主机上的 of --remote 把请求发送过来。
+// 连接可以通过 ssh -R 转发，例如：
+//
+//	ssh -R 7077:127.0.0.1:7077 devbox                   # TCP
+//	ssh -R /tmp/of.sock:$HOME/.of/serve.sock devbox     # Unix socket
+//
+// 协议是一问一答的 JSON，每个连接处理一个请求。
+
+const (
+	defaultRemoteAddress = "127.0.0.1:7077"
+	remoteTimeout        = 30 * time.Second
+	maxRemoteContent     = 16 << 20 // 超过此大小的文件改为发送 URL
+	maxRemoteRequest     = maxRemoteContent*4/3 + 64<<10
+)
+
+// remote 是否把请求发送到 of serve
+var remote bool
+
+// remoteRequest 客户端发送的请求
+type remoteRequest struct {
+	Token   string       `json:"token"`
+	Action  pickerAction `json:"action"` // open, reveal, copy
+	Host    string       `json:"host"`   // 发送请求的主机名
+	Path    string       `json:"path"`   // 远程主机上的路径
+	Name    string       `json:"name,omitempty"`
+	Content string       `json:"content,omitempty"` // base64 编码的文件内容
+	URL     string       `json:"url,omitempty"`     // 文件无法直接发送时使用的 sftp:// 等 URL
+	Line    int          `json:"line,omitempty"`
+	Col     int          `json:"col,omitempty"`
+}
+
+// remoteResponse 服务端的响应
+type remoteResponse struct {
+	OK    bool   `json:"ok"`
+	App   string `json:"app,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// parseRemoteAddress 解析地址：unix:/path 或包含 / 的路径为 Unix socket，其他为 TCP
+func parseRemoteAddress(addr string) (string, string) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	case strings.HasPrefix(addr, "tcp:"):
+		return "tcp", strings.TrimPrefix(addr, "tcp:")
+	case strings.ContainsAny(addr, `/\`) || strings.HasSuffix(addr, ".sock"):
+		return "unix", addr
+	default:
+		return "tcp", addr
+	}
+}
+
+// remoteAddress 返回客户端使用的地址：OF_REMOTE -> remote_address 配置 -> 默认地址
+func remoteAddress() string {
+	if addr := os.Getenv("OF_REMOTE"); addr != "" {
+		return addr
+	}
+	if config.RemoteAddress != "" {
+		return config.RemoteAddress
+	}
+	return defaultRemoteAddress
+}
+
+// remoteToken 返回共享令牌：OF_REMOTE_TOKEN -> serve_token 配置
+func remoteToken() string {
+	if token := os.Getenv("OF_REMOTE_TOKEN"); token != "" {
+		return token
+	}
+	return config.ServeToken
+}
+
+// generateToken 生成随机令牌
+func generateToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// remoteURL 返回远程路径对应的 URL
+// 配置了 remote_url（例如 "sftp://me@devbox{path}"）时使用配置，否则为 sftp://user@host/path
+func remoteURL(path string) string {
+	p := filepath.ToSlash(path)
+	if config.RemoteURL != "" {
+		return strings.ReplaceAll(config.RemoteURL, "{path}", p)
+	}
+
+	host, _ := os.Hostname()
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		host = u.Username + "@" + host
+	}
+	return "sftp://" + host + p
+}
+
+// newRemoteRequest 为本地路径构造请求
+// 不太大的文件直接发送内容，文件夹和大文件发送 URL
+func newRemoteRequest(action pickerAction, t templateContext) (remoteRequest, error) {
+	host, _ := os.Hostname()
+	req := remoteRequest{
+		Token:  remoteToken(),
+		Action: action,
+		Host:   host,
+		Path:   t.Path,
+		Name:   filepath.Base(t.Path),
+		Line:   t.Line,
+		Col:    t.Col,
+	}
+
+	switch {
+	case t.URL != "":
+		req.URL = t.URL
+	case action == actionCopy:
+		// 只复制路径本身
+	case isFile(t.Path):
+		info, err := os.Stat(t.Path)
+		if err != nil {
+			return req, err
+		}
+		if info.Size() > maxRemoteContent {
+			req.URL = remoteURL(t.Path)
+			break
+		}
+		data, err := os.ReadFile(t.Path)
+		if err != nil {
+			return req, err
+		}
+		req.Content = base64.StdEncoding.EncodeToString(data)
+	default:
+		req.URL = remoteURL(t.Path)
+	}
+	return req, nil
+}
+
+// sendRemoteRequest 发送请求并等待响应
+func sendRemoteRequest(req remoteRequest) (remoteResponse, error) {
+	var resp remoteResponse
+	network, address := parseRemoteAddress(remoteAddress())
+	if debug {
+		fmt.Printf("🔍 Remote: %s %s -> %s:%s\n", req.Action, req.Path, network, address)
+	}
+
+	conn, err := net.DialTimeout(network, address, remoteTimeout)
+	if err != nil {
+		return resp, fmt.Errorf("cannot connect to of serve at %s: %v", address, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(remoteTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, err
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("invalid response from of serve: %v", err)
+	}
+	if !resp.OK {
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
+
+// remoteOpen 把路径发送到 of serve，返回对方使用的应用
+func remoteOpen(action pickerAction, t templateContext) (string, error) {
+	req, err := newRemoteRequest(action, t)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		fmt.Printf("🧪 Would send %s request to %s\n", req.Action, remoteAddress())
+		return "of serve", nil
+	}
+	resp, err := sendRemoteRequest(req)
+	return resp.App, err
+}
+
+// remoteOpenTargets 逐个发送路径并输出结果，返回失败的数量
+func remoteOpenTargets(action pickerAction, targets []templateContext) int {
+	failed := 0
+	for _, t := range targets {
+		app, err := remoteOpen(action, t)
+		label := formatLocation(t)
+		if t.URL != "" {
+			label = t.URL
+		}
+		if err != nil {
+			fmt.Printf("❌ Error: cannot open remotely: %s: %v\n", label, err)
+			failed++
+			continue
+		}
+		if !dryRun {
+			fmt.Printf("📡 Opened remotely in %s: %s\n", app, label)
+		}
+	}
+	return failed
+}
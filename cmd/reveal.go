@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// freedesktop 文件管理器 D-Bus 接口
+const (
+	fileManagerDest   = "org.freedesktop.FileManager1"
+	fileManagerObject = "/org/freedesktop/FileManager1"
+	fileManagerMethod = "org.freedesktop.FileManager1.ShowItems"
+)
+
+// dbusShowItemsCommands 返回调用 ShowItems 的候选命令（gdbus 优先，dbus-send 兜底）
+func dbusShowItemsCommands(path string) []Command {
+	uri := fileURI(path)
+	return []Command{
+		{
+			Name: "gdbus",
+			Args: []string{
+				"call", "--session",
+				"--dest", fileManagerDest,
+				"--object-path", fileManagerObject,
+				"--method", fileManagerMethod,
+				fmt.Sprintf("['%s']", uri), "",
+			},
+		},
+		{
+			Name: "dbus-send",
+			Args: []string{
+				"--session", "--print-reply", "--type=method_call",
+				"--dest=" + fileManagerDest,
+				fileManagerObject, fileManagerMethod,
+				"array:string:" + uri, "string:",
+			},
+		},
+	}
+}
+
+// revealPath 打开父目录并选中指定的文件或文件夹
+// 依次尝试：平台原生命令（open -R、explorer /select,）、freedesktop D-Bus ShowItems，
+// 都不可用时退回到直接打开父目录
+func revealPath(path string) error {
+	// 没有图形界面时无法选中，直接对路径本身应用 headless_fallback
+	if manager == "" && useHeadlessFallback() {
+		return headlessOpen(path)
+	}
+
+	// 通过 -m 指定了管理器时无法知道其选中语法，直接打开父目录；
+	// default_manager 只在原生选中不可用时使用
+	if !explicitManager() {
+		if cmd, ok := revealCommand(path); ok {
+			err := launcher.Launch(cmd)
+			if err == nil {
+				return nil
+			}
+			if debug {
+				fmt.Printf("🔍 Reveal via %s failed: %v\n", cmd.Name, err)
+			}
+		}
+
+		for _, cmd := range dbusShowItemsCommands(path) {
+			if !isCommandAvailable(cmd.Name) {
+				continue
+			}
+			err := launcher.Launch(cmd)
+			if err == nil {
+				return nil
+			}
+			if debug {
+				fmt.Printf("🔍 Reveal via D-Bus (%s) failed: %v\n", cmd.Name, err)
+			}
+		}
+	}
+
+	parent := filepath.Dir(path)
+	if debug {
+		fmt.Printf("🔍 Selection not supported, opening parent directory: %s\n", parent)
+	}
+	return openInFileManager(parent)
+}
+
+// revealCommand 返回当前 Opener 提供的选中命令
+func revealCommand(path string) (Command, bool) {
+	opener, err := selectOpenerFor(canReveal)
+	if err != nil {
+		return Command{}, false
+	}
+	return opener.RevealCommand(path)
+}
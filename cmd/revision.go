@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 打开文件的历史版本：of cmd/root.go@HEAD~3 或 of --rev v1.2 config.yaml
+// 文件内容从本地仓库读取，按原文件名写入用户缓存目录 <缓存>/of/rev/<仓库>@<提交>/<目录>/，
+// 因此扩展名不变，仍然按文件类型选择应用；超过 rev_max_age_hours 的临时文件会被清理
+
+// revision --rev 指定的版本
+var revision string
+
+// revSources 本次运行中取出的历史版本文件对应的原文件路径
+var revSources = map[string]string{}
+
+// defaultRevMaxAgeHours 历史版本临时文件的默认保留时间（小时）
+const defaultRevMaxAgeHours = 24
+
+// revTempDir 返回保存历史版本的目录
+func revTempDir() string {
+	return userCacheSubdir("rev")
+}
+
+// isRevTempPath 判断路径是否为历史版本的临时文件（不加入历史记录）
+func isRevTempPath(path string) bool {
+	rel, err := filepath.Rel(revTempDir(), path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// revSourcePath 返回历史版本临时文件对应的仓库中的原路径，其他路径原样返回
+// 临时目录中没有仓库的目录结构和标记文件，规则需要按原路径匹配
+func revSourcePath(path string) string {
+	if source, ok := revSources[path]; ok {
+		return source
+	}
+	return path
+}
+
+// splitRevSpec 拆分 file@REV，没有版本时返回空字符串
+// 开头的 @ 是书签，不作为版本处理
+func splitRevSpec(arg string) (string, string) {
+	i := strings.LastIndex(arg, "@")
+	if i <= 0 || i == len(arg)-1 || strings.ContainsAny(arg[i-1:i], `/\`) {
+		return arg, ""
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// checkoutRevision 把文件在指定版本的内容写入临时目录，返回临时文件路径
+func checkoutRevision(path, rev string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	repo, err := openGitRepo(absPath)
+	if err != nil {
+		return "", err
+	}
+	commit, err := repo.resolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(repo.Root, absPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("not a file inside the repository")
+	}
+	if debug {
+		fmt.Printf("🔍 Revision: %s -> %s, file: %s\n", rev, commit, filepath.ToSlash(rel))
+	}
+
+	// 保留仓库中的目录结构，不同目录下的同名文件不会冲突
+	dir := filepath.Join(revTempDir(), filepath.Base(repo.Root)+"@"+commit[:12], filepath.Dir(rel))
+	local := filepath.Join(dir, filepath.Base(absPath))
+
+	// --dry-run 和 explain 只确认文件存在，不写入也不清理临时目录
+	if dryRun {
+		if _, err := repo.readFileAtCommit(commit, filepath.ToSlash(rel)); err != nil {
+			return "", err
+		}
+		revSources[local] = absPath
+		return local, nil
+	}
+
+	cleanRevTempDir()
+	if info, err := os.Lstat(local); err == nil {
+		if info.Mode().IsRegular() {
+			// 同一提交中的文件内容不会变化，直接复用；更新时间以免被清理
+			now := time.Now()
+			_ = os.Chtimes(local, now, now)
+			revSources[local] = absPath
+			return local, nil
+		}
+		// 不是普通文件（例如符号链接）时不使用，重新写入
+		if err := os.RemoveAll(local); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := repo.readFileAtCommit(commit, filepath.ToSlash(rel))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	// 历史版本只读，避免误以为修改会生效；O_EXCL 保证不会写到已存在的文件或符号链接
+	f, err := os.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(local)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(local)
+		return "", err
+	}
+	revSources[local] = absPath
+	return local, nil
+}
+
+// cleanRevTempDir 删除超过保留时间的历史版本文件和空目录
+func cleanRevTempDir() {
+	removeOldFiles(revTempDir(), config.RevMaxAgeHours)
+}
+
+// removeOldFiles 删除 root 中超过 maxAgeHours 小时未修改的文件以及清理后留下的空目录
+// maxAgeHours 不大于 0 时不清理
+func removeOldFiles(root string, maxAgeHours int) {
+	if maxAgeHours <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-time.Duration(maxAgeHours) * time.Hour)
+
+	var dirs []string
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Chmod(p, 0600) // Windows 上只读文件无法删除
+			if err := os.Remove(p); err == nil && debug {
+				fmt.Printf("🔍 Removed old temp file: %s\n", p)
+			}
+		}
+		return nil
+	})
+
+	// 从最深的目录开始删除空目录
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
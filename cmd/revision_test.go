@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckoutRevision(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the cache directory is set through XDG_CACHE_HOME")
+	}
+	r := newTestRepo(t)
+	r.commit("one", map[string]string{"src/a.go": "package a\n"})
+	r.commit("two", map[string]string{"src/a.go": "package a\n\nfunc A() {}\n"})
+	source := filepath.Join(r.dir, "src", "a.go")
+
+	for _, dry := range []bool{true, false} {
+		t.Setenv("XDG_CACHE_HOME", t.TempDir())
+		saved := dryRun
+		dryRun = dry
+		local, err := checkoutRevision(source, "HEAD~1")
+		dryRun = saved
+		if err != nil {
+			t.Fatalf("checkoutRevision(dry=%v): %v", dry, err)
+		}
+		t.Cleanup(func() { delete(revSources, local) })
+
+		if got := revSourcePath(local); got != source {
+			t.Errorf("revSourcePath(%s) = %s, want %s", local, got, source)
+		}
+		if !isFile(local) {
+			t.Errorf("isFile(%s) = false (dry=%v)", local, dry)
+		}
+
+		// --dry-run 和 explain 不在缓存目录中写入任何文件
+		_, err = os.Lstat(local)
+		if dry {
+			if !os.IsNotExist(err) {
+				t.Errorf("dry run wrote %s: %v", local, err)
+			}
+			continue
+		}
+		data, err := os.ReadFile(local)
+		if err != nil || string(data) != "package a\n" {
+			t.Errorf("checkoutRevision wrote %q, %v", data, err)
+		}
+	}
+
+	if _, err := checkoutRevision(filepath.Join(r.dir, "src", "missing.go"), "HEAD"); err == nil {
+		t.Error("checkoutRevision(missing file): expected an error")
+	}
+}
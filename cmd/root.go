@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -18,18 +21,47 @@ var (
 	debug           bool
 	manager         string
 	copyToClipboard bool
+	reveal          bool
+	dryRun          bool
+	gitRootTarget   bool
+	webTarget       bool
+	blameTarget     bool
+	waitForExit     bool
 
 	// 配置结构体
 	config struct {
-		DefaultManager string            `mapstructure:"default_manager"`
-		CustomManagers map[string]string `mapstructure:"custom_managers"`
-		RecentPaths    []string          `mapstructure:"recent_paths"`
-		MaxRecent      int               `mapstructure:"max_recent"`
-		FileTypeApps   map[string]string `mapstructure:"file_type_apps"`
+		DefaultManager    string                   `mapstructure:"default_manager"`
+		CustomManagers    map[string]managerConfig `mapstructure:"custom_managers"`
+		RecentPaths       []string                 `mapstructure:"recent_paths"` // 旧版最近使用列表，仅用于迁移
+		MaxRecent         int                      `mapstructure:"max_recent"`
+		History           []historyEntry           `mapstructure:"history"` // 旧版历史记录，仅用于迁移
+		HistoryMaxAge     float64                  `mapstructure:"history_max_age"`
+		Bookmarks         map[string]string        `mapstructure:"bookmarks"`
+		FileTypeApps      map[string]string        `mapstructure:"file_type_apps"`
+		FileGroups        map[string][]string      `mapstructure:"file_groups"`     // 自定义文件类型组，覆盖同名的内置组
+		EditorArgs        map[string]string        `mapstructure:"editor_args"`     // 编辑器跳转到行号的参数约定，覆盖内置值
+		SchemeHandlers    map[string]string        `mapstructure:"scheme_handlers"` // URL scheme 对应的应用，未配置时使用平台默认方式
+		Opener            string                   `mapstructure:"opener"`
+		TerminalManager   string                   `mapstructure:"terminal_manager"`     // default_manager 为 auto 且没有图形界面时使用
+		HeadlessFallback  string                   `mapstructure:"headless_fallback"`    // 没有图形界面时代替平台打开方式的策略
+		HeadlessCommand   string                   `mapstructure:"headless_command"`     // headless_fallback 为 terminal 时运行的命令
+		RemoteAddress     string                   `mapstructure:"remote_address"`       // of --remote 连接的地址
+		RemoteURL         string                   `mapstructure:"remote_url"`           // 无法直接发送的文件使用的 URL 模板
+		ServeToken        string                   `mapstructure:"serve_token"`          // of serve 和 of --remote 共享的令牌
+		ServeListen       string                   `mapstructure:"serve_listen"`         // of serve 监听的地址
+		RemoteMaxAgeHours int                      `mapstructure:"remote_max_age_hours"` // of serve 收到的文件的保留时间，0 为不清理
+		Clipboard         string                   `mapstructure:"clipboard"`            // 剪切板后端，空或 auto 为自动选择
+		CopyFormat        string                   `mapstructure:"copy_format"`          // --copy 默认使用的格式
+		CopySeparator     string                   `mapstructure:"copy_separator"`       // --copy 多个路径之间的分隔符
+		Rules             []appRule                `mapstructure:"rules"`                // 按顺序匹配的应用规则，优先于 file_type_apps
+		DirectoryRules    []appRule                `mapstructure:"directory_rules"`      // 文件夹使用的应用规则，没有命中时使用文件管理器
+		MarkerDepth       int                      `mapstructure:"marker_depth"`         // 查找标记文件时最多向上查找的层数
+		GitForges         map[string][]string      `mapstructure:"git_forges"`           // 代码托管平台类型对应的自建主机列表
+		RevMaxAgeHours    int                      `mapstructure:"rev_max_age_hours"`    // 历史版本临时文件的保留时间，0 为不清理
 	}
 
 	rootCmd = &cobra.Command{
-		Use:   "of [path]",
+		Use:   "of [path...]",
 		Short: "open your file or directory in file manager",
 		Long: `Open files or directories in file manager from terminal.
 
@@ -39,12 +71,33 @@ Examples:
   of -p /path/to/file   # 使用标志指定路径
   of -m finder          # 指定文件管理器
   of --debug            # 启用调试模式
-  of --copy             # 复制路径到剪切板`,
-		Args: cobra.MaximumNArgs(1),
+  of --copy             # 复制路径到剪切板
+  of --reveal file.txt  # 在文件管理器中选中文件
+  of --dry-run file.txt # 只打印将要执行的命令
+  of --wait file.txt    # 等待应用退出（可作为 $EDITOR 使用）
+  of -i                 # 交互式选择最近使用的路径
+  of @work/src          # 打开书签下的路径
+  of a.txt b.pdf src    # 一次打开多个路径
+  of '*.pdf'            # 打开匹配通配符的所有文件
+  of https://go.dev     # 打开 URL（按 scheme_handlers 选择应用）`,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeBookmarkPaths,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// 只打印命令，不真正执行
+			if dryRun {
+				launcher = &dryRunLauncher{}
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// 加载配置
 			loadConfig()
 
+			// 交互式选择最近使用的路径
+			if interactive {
+				runPicker(strings.Join(args, " "))
+				return
+			}
+
 			// 调试模式
 			if debug {
 				fmt.Printf("🔍 Debug mode enabled\n")
@@ -52,105 +105,194 @@ Examples:
 				fmt.Printf("🔍 Manager: %s\n", manager)
 			}
 
-			// 获取要打开的路径
-			targetPath := path
-			if len(args) > 0 {
-				targetPath = args[0]
+			// 指定了复制格式时等同于 --copy，--blame 等同于 --web
+			if copyFormat != "" {
+				copyToClipboard = true
+			}
+			if blameTarget {
+				webTarget = true
+			}
+
+			// 如果没有提供子命令且没有指定路径，显示帮助信息
+			if len(args) == 0 && path == "" && !copyToClipboard && !reveal && !gitRootTarget && !webTarget {
+				if err := cmd.Help(); err != nil {
+					fmt.Printf("❌ Error: cannot display help: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			// 获取要打开的路径（-p 和位置参数可以同时使用）
+			targets := args
+			if path != "" {
+				targets = append([]string{path}, args...)
 			}
 
 			// 如果路径为空，使用当前目录
-			if targetPath == "" {
+			if len(targets) == 0 {
 				currentDir, err := os.Getwd()
 				if err != nil {
 					fmt.Printf("❌ Error: cannot get current directory: %v\n", err)
 					os.Exit(1)
 				}
-				targetPath = currentDir
+				targets = []string{currentDir}
 			}
 
-			// 检查路径是否存在
-			if !isPathValid(targetPath) {
-				fmt.Printf("❌ Error: path does not exist: %s\n", targetPath)
+			// 展开书签和通配符，获取绝对路径
+			resolved, failures := resolvePathArgs(targets)
+			if gitRootTarget || webTarget {
+				var gitFailures []error
+				resolved, gitFailures = gitTargets(resolved, gitRootTarget, webTarget, blameTarget)
+				failures = append(failures, gitFailures...)
+			}
+			if len(targets) == 1 && len(failures) > 0 {
+				fmt.Printf("❌ Error: %v\n", failures[0])
 				os.Exit(1)
 			}
-
-			// 获取绝对路径
-			absPath, err := filepath.Abs(targetPath)
-			if err != nil {
-				fmt.Printf("❌ Error: cannot get absolute path: %v\n", err)
+			for _, f := range failures {
+				fmt.Printf("❌ %v\n", f)
+			}
+			if len(resolved) == 0 {
 				os.Exit(1)
 			}
 
-			// 如果指定了复制到剪切板
-			if copyToClipboard {
-				if err := copyToClipboardPath(absPath); err != nil {
-					fmt.Printf("❌ Error: cannot copy path to clipboard: %v\n", err)
-					os.Exit(1)
+			// 发送到另一台机器上的 of serve
+			if remote {
+				action := actionOpen
+				switch {
+				case copyToClipboard:
+					action = actionCopy
+				case reveal:
+					action = actionReveal
 				}
-				fmt.Printf("📋 Path copied to clipboard: %s\n", absPath)
+				exitOnFailures(len(failures) + remoteOpenTargets(action, resolved))
 				return
 			}
 
-			// 如果没有提供子命令且没有指定路径，显示帮助信息
-			if len(args) == 0 && path == "" && !copyToClipboard {
-				if err := cmd.Help(); err != nil {
-					fmt.Printf("❌ Error: cannot display help: %v\n", err)
+			absPaths := make([]string, 0, len(resolved))
+			for _, t := range resolved {
+				absPaths = append(absPaths, t.Path)
+			}
+
+			// 如果指定了复制到剪切板，按 --copy-format 转换后复制，多个路径默认每行一个
+			if copyToClipboard {
+				format, err := resolveCopyFormat()
+				if err != nil {
+					fmt.Printf("❌ Error: %v\n", err)
+					os.Exit(1)
+				}
+				text, err := formatCopyTargets(format, resolved)
+				if err != nil {
+					fmt.Printf("❌ Error: %v\n", err)
 					os.Exit(1)
 				}
+				if err := copyToClipboardPath(text); err != nil {
+					fmt.Printf("❌ Error: cannot copy path to clipboard: %v\n", err)
+					os.Exit(1)
+				}
+				switch {
+				case dryRun:
+					// 只打印了命令
+				case format == copyFormatContent && len(absPaths) == 1:
+					fmt.Printf("📋 Contents copied to clipboard: %s\n", absPaths[0])
+				case format == copyFormatContent:
+					fmt.Printf("📋 Contents of %d files copied to clipboard\n", len(absPaths))
+				case len(absPaths) == 1:
+					fmt.Printf("📋 Path copied to clipboard: %s\n", text)
+				default:
+					fmt.Printf("📋 %d paths copied to clipboard\n", len(absPaths))
+				}
+				exitOnFailures(len(failures))
 				return
 			}
 
 			// 如果没有指定管理器，使用默认管理器
-			if manager == "" && config.DefaultManager != "" {
-				manager = config.DefaultManager
-				if debug {
-					fmt.Printf("🔍 Using default manager: %s\n", manager)
-				}
-			}
-
-			// 检查是否为文件，如果是文件则根据文件类型选择应用程序
-			if isFile(absPath) {
-				appForFile := getAppForFileType(absPath)
-				if appForFile != "" {
-					if debug {
-						fmt.Printf("🔍 File type detected, using app: %s\n", appForFile)
+			useDefaultManager()
+
+			var results []openResult
+			if reveal {
+				// 在父目录中选中文件
+				for _, t := range resolved {
+					r := openResult{Target: t, App: fileManagerLabel()}
+					if t.URL != "" {
+						r.Err = fmt.Errorf("cannot reveal a URL: %s", t.URL)
+					} else {
+						r.Err = revealPath(t.Path)
 					}
-					err = openFileWithApp(absPath, appForFile)
-				} else {
-					// 没有配置的文件类型使用默认文件管理器
-					err = openInFileManager(absPath)
+					results = append(results, r)
 				}
 			} else {
-				// 文件夹使用默认文件管理器
-				err = openInFileManager(absPath)
+				results = openPaths(resolved)
 			}
 
-			if err != nil {
-				fmt.Printf("❌ Error: cannot open path: %v\n", err)
-				os.Exit(1)
+			// 只有成功打开的本地路径才加入历史记录
+			var opened, history []string
+			for _, r := range results {
+				if r.Err != nil {
+					continue
+				}
+				opened = append(opened, r.Target.Path)
+				if r.Target.URL == "" && !isRevTempPath(r.Target.Path) {
+					history = append(history, r.Target.Path)
+				}
 			}
-
-			// 添加到最近使用列表
-			addToRecentPaths(absPath)
-
-			// 确定使用的应用程序或文件管理器名称
-			var usedApp string
-			if isFile(absPath) {
-				appForFile := getAppForFileType(absPath)
-				if appForFile != "" {
-					usedApp = appForFile
-				} else {
-					usedApp = getFileManagerName()
+			addToHistory(history...)
+
+			// 单个路径保持原有的输出格式
+			if len(targets) == 1 && len(results) == 1 {
+				r := results[0]
+				switch {
+				case r.Err != nil && r.Target.URL != "":
+					fmt.Printf("❌ Error: cannot open URL: %v\n", r.Err)
+					os.Exit(exitCode(r.Err))
+				case r.Err != nil && reveal:
+					fmt.Printf("❌ Error: cannot reveal path: %v\n", r.Err)
+					os.Exit(1)
+				case r.Err != nil:
+					fmt.Printf("❌ Error: cannot open path: %v\n", r.Err)
+					os.Exit(exitCode(r.Err))
+				case dryRun:
+					// 只打印了命令
+				case reveal:
+					fmt.Printf("🔎 Revealed in %s: %s\n", r.App, formatPath(r.Target.Path))
+				case r.Target.URL != "":
+					fmt.Printf("🌐 Opened in %s: %s\n", r.App, r.Target.URL)
+				default:
+					fmt.Printf("🚀 Opened in %s: %s\n", r.App, formatLocation(r.Target))
 				}
-			} else {
-				usedApp = getFileManagerName()
+				return
 			}
 
-			if manager != "" {
-				usedApp = manager
+			for _, r := range results {
+				switch {
+				case r.Err != nil:
+					fmt.Printf("❌ %s: %v\n", formatLocation(r.Target), r.Err)
+				case dryRun:
+				case reveal:
+					fmt.Printf("🔎 Revealed in %s: %s\n", r.App, formatPath(r.Target.Path))
+				case r.Target.URL != "":
+					fmt.Printf("🌐 Opened in %s: %s\n", r.App, r.Target.URL)
+				default:
+					fmt.Printf("🚀 Opened in %s: %s\n", r.App, formatLocation(r.Target))
+				}
 			}
 
-			fmt.Printf("🚀 Opened in %s: %s\n", usedApp, formatPath(absPath))
+			total := len(results) + len(failures)
+			if dryRun {
+				exitOnFailures(total - len(opened))
+				return
+			}
+			verb := "Opened"
+			if reveal {
+				verb = "Revealed"
+			}
+			fmt.Printf("📊 %s %d of %d paths\n", verb, len(opened), total)
+			for _, r := range results {
+				if r.Err != nil {
+					os.Exit(exitCode(r.Err))
+				}
+			}
+			exitOnFailures(len(failures))
 		},
 	}
 )
@@ -161,9 +303,89 @@ func Execute() error {
 
 func init() {
 	rootCmd.Flags().StringVarP(&path, "path", "p", "", "path to file or directory to open")
+	_ = rootCmd.RegisterFlagCompletionFunc("path", completeBookmarkPaths)
 	rootCmd.Flags().StringVarP(&manager, "manager", "m", "", "specify file manager to use")
-	rootCmd.Flags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
+	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the commands that would run without running them")
+	rootCmd.PersistentFlags().BoolVarP(&waitForExit, "wait", "w", false, "wait for the app to exit and return its exit status")
 	rootCmd.Flags().BoolVarP(&copyToClipboard, "copy", "c", false, "copy path to clipboard")
+	rootCmd.Flags().StringVar(&copyFormat, "copy-format", "", "copy in this format (implies --copy): "+strings.Join(copyFormats, ", "))
+	_ = rootCmd.RegisterFlagCompletionFunc("copy-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return copyFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.Flags().StringVar(&copySeparator, "copy-separator", "", "separator between copied paths (default: newline)")
+	rootCmd.Flags().BoolVarP(&reveal, "reveal", "r", false, "open the parent folder with the path selected")
+	rootCmd.Flags().BoolVar(&plainDirectories, "plain", false, "always open folders in the file manager, ignoring directory_rules")
+	rootCmd.Flags().BoolVar(&remote, "remote", false, "send the request to 'of serve' on another machine")
+	rootCmd.Flags().BoolVar(&gitRootTarget, "git-root", false, "use the root of the enclosing Git repository")
+	rootCmd.Flags().BoolVar(&webTarget, "web", false, "use the file's page on GitHub/GitLab/Gitea/Bitbucket")
+	rootCmd.Flags().BoolVar(&blameTarget, "blame", false, "like --web, but link to the blame page")
+	rootCmd.Flags().StringVar(&revision, "rev", "", "open files as they were at this Git revision (e.g. HEAD~3, v1.2)")
+	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "pick a recent path interactively")
+}
+
+// managerFromConfig manager 来自 default_manager 而不是 -m
+var managerFromConfig bool
+
+// explicitManager 判断是否通过 -m 指定了管理器
+func explicitManager() bool {
+	return manager != "" && !managerFromConfig
+}
+
+// useDefaultManager 没有通过 -m 指定管理器时使用配置中的默认管理器
+// 管理器为 auto 时按是否有图形界面选择
+func useDefaultManager() {
+	if manager == "" && config.DefaultManager != "" {
+		manager = config.DefaultManager
+		managerFromConfig = true
+		if debug {
+			fmt.Printf("🔍 Using default manager: %s\n", manager)
+		}
+	}
+	resolveAutoManager()
+}
+
+// exitCode 返回错误对应的退出码
+// 应用自身以非零状态退出时（例如 --wait）原样传递，其他错误为 1
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// exitOnFailures 有路径处理失败时以非零状态退出
+func exitOnFailures(failed int) {
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// openPath 打开绝对路径：文件按类型选择应用程序，文件夹按 directory_rules 选择，否则使用文件管理器
+// 返回实际使用的应用程序或文件管理器名称
+func openPath(absPath string) (string, error) {
+	if app := getAppForPath(absPath); app != "" {
+		if debug {
+			fmt.Printf("🔍 File type detected, using app: %s\n", app)
+		}
+		return app, openFileWithApp(absPath, app)
+	}
+
+	// 没有命中规则的文件夹和没有配置的文件类型使用默认文件管理器
+	return fileManagerLabel(), openInFileManager(absPath)
+}
+
+// fileManagerLabel 返回 openInFileManager 实际使用的管理器名称，用于输出
+func fileManagerLabel() string {
+	switch {
+	case manager != "":
+		return manager
+	case useHeadlessFallback():
+		return headlessLabel()
+	default:
+		return getFileManagerName()
+	}
 }
 
 // openInFileManager 使用系统默认的文件管理器打开文件或文件夹
@@ -174,38 +396,67 @@ func openInFileManager(path string) error {
 			if debug {
 				fmt.Printf("🔍 Using custom manager: %s -> %s\n", manager, customCmd)
 			}
-			cmd := exec.Command(customCmd, path)
-			return cmd.Run()
+			return launchTemplate(customCmd, templateContext{Path: path}, isTerminalManager(manager))
 		}
 
-		// 尝试直接使用指定的管理器名称
+		// 尝试直接使用指定的管理器名称（同样支持模板）
 		if debug {
 			fmt.Printf("🔍 Trying direct manager: %s\n", manager)
 		}
-		cmd := exec.Command(manager, path)
-		if err := cmd.Run(); err == nil {
-			return nil
+		// 管理器已经运行（--wait 时以非零状态退出）则不再回退
+		var exitErr *exec.ExitError
+		if err := launchTemplate(manager, templateContext{Path: path}, isTerminalManager(manager)); err == nil || errors.As(err, &exitErr) {
+			return err
 		}
 	}
 
-	switch runtime.GOOS {
-	case "darwin":
-		// macOS - 使用 Finder
-		cmd := exec.Command("open", path)
-		return cmd.Run()
-	case "windows":
-		// Windows - 使用 Explorer
-		cmd := exec.Command("explorer", path)
-		_ = cmd.Run() // Windows explorer 即使成功打开文件夹也可能返回非零状态码
-		// 所以我们忽略错误，因为文件夹实际上已经被打开了
-		return nil
-	case "linux":
-		// Linux - 尝试使用 xdg-open
-		cmd := exec.Command("xdg-open", path)
-		return cmd.Run()
-	default:
-		return fmt.Errorf("⚠️ unsupported operating system: %s", runtime.GOOS)
+	// 没有图形界面时平台打开方式无效，使用 headless_fallback
+	if useHeadlessFallback() {
+		return headlessOpen(path)
 	}
+
+	opener, err := selectOpenerFor(canOpenPath)
+	if err != nil {
+		return err
+	}
+	if err := checkOpenerWait(opener); err != nil {
+		return err
+	}
+	return launcher.Launch(opener.OpenCommand(path))
+}
+
+// launchTemplate 展开命令模板并启动
+// 终端管理器连接到当前终端运行，其他管理器在后台启动
+func launchTemplate(tmpl string, ctx templateContext, terminal bool) error {
+	cmd, err := expandCommand(tmpl, ctx)
+	if err != nil {
+		return err
+	}
+	terminal = terminal || isTerminalEditor(cmd.Name)
+	cmd.Terminal = terminal
+	cmd.Detach = !terminal
+	return launcher.Launch(cmd)
+}
+
+// detachLaunches 将应用命令标记为后台启动，--wait 时会改为前台等待
+// 终端编辑器（vim、nano 等）在后台运行时标准输入输出连接到空设备，无法使用，改为连接到当前终端
+func detachLaunches(launches []appLaunch, err error) ([]appLaunch, error) {
+	for i := range launches {
+		if isTerminalEditor(launches[i].Command.Name) {
+			launches[i].Command.Terminal = true
+		} else {
+			launches[i].Command.Detach = true
+		}
+	}
+	return launches, err
+}
+
+// terminalLaunches 将应用命令标记为连接到当前终端运行
+func terminalLaunches(launches []appLaunch, err error) ([]appLaunch, error) {
+	for i := range launches {
+		launches[i].Command.Terminal = true
+	}
+	return launches, err
 }
 
 // getFileManagerName 获取当前平台的文件管理器名称
@@ -287,60 +538,201 @@ func loadConfig() {
 	// 设置默认值
 	viper.SetDefault("default_manager", "")
 	viper.SetDefault("custom_managers", map[string]string{})
-	viper.SetDefault("recent_paths", []string{})
 	viper.SetDefault("max_recent", 10)
+	viper.SetDefault("history_max_age", defaultHistoryMaxAge)
 	viper.SetDefault("file_type_apps", map[string]string{})
+	viper.SetDefault("file_groups", map[string][]string{})
+	viper.SetDefault("rules", []map[string]interface{}{})
+	viper.SetDefault("directory_rules", []map[string]interface{}{})
+	viper.SetDefault("marker_depth", defaultMarkerDepth)
+	viper.SetDefault("editor_args", map[string]string{})
+	viper.SetDefault("scheme_handlers", map[string]string{})
+	viper.SetDefault("opener", "")
+	viper.SetDefault("terminal_manager", "")
+	viper.SetDefault("headless_fallback", headlessPrint)
+	viper.SetDefault("headless_command", "")
+	viper.SetDefault("remote_address", "")
+	viper.SetDefault("remote_url", "")
+	viper.SetDefault("serve_token", "")
+	viper.SetDefault("serve_listen", "")
+	viper.SetDefault("remote_max_age_hours", defaultRemoteMaxAgeHours)
+	viper.SetDefault("clipboard", clipboardAuto)
+	viper.SetDefault("copy_format", copyFormatPath)
+	viper.SetDefault("copy_separator", "")
+	viper.SetDefault("git_forges", map[string][]string{})
+	viper.SetDefault("rev_max_age_hours", defaultRevMaxAgeHours)
+	viper.SetDefault("bookmarks", map[string]string{})
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
 		if debug {
 			fmt.Printf("🔍 No config file found, using defaults\n")
 		}
-		// 创建默认配置文件
-		if err := viper.SafeWriteConfig(); err != nil {
-			if debug {
-				fmt.Printf("⚠️ Warning: cannot write config file: %v\n", err)
+		// 创建默认配置文件（--dry-run 时不写入）
+		if !dryRun {
+			if err := viper.SafeWriteConfig(); err != nil {
+				if debug {
+					fmt.Printf("⚠️ Warning: cannot write config file: %v\n", err)
+				}
 			}
 		}
 	}
 
 	// 解析配置到结构体
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&config, viper.DecodeHook(configDecodeHook)); err != nil {
 		if debug {
 			fmt.Printf("⚠️ Warning: cannot parse config: %v\n", err)
 		}
 	}
+	compileRules()
+
+	// 含点的键以完整的映射交给 viper，之后 WriteConfig 会原样写回，不会拆成嵌套的映射
+	for key, values := range map[string]map[string]string{
+		"file_type_apps":  config.FileTypeApps,
+		"editor_args":     config.EditorArgs,
+		"scheme_handlers": config.SchemeHandlers,
+		"bookmarks":       config.Bookmarks, // 旧版本允许书签名含点
+	} {
+		for k := range values {
+			if strings.Contains(k, ".") {
+				viper.Set(key, values)
+				break
+			}
+		}
+	}
+
+	// 历史记录保存在状态目录中，与配置文件分开
+	initDataDir(home, configDir)
+
+	// 旧版 recent_paths/history 迁移到历史记录文件（--dry-run 时不修改任何文件）
+	if !dryRun {
+		migrateLegacyHistory()
+	}
+}
+
+// writeConfig 保存配置文件；--dry-run 时只提示而不写入
+func writeConfig() error {
+	if dryRun {
+		fmt.Printf("📝 Dry run: config not saved to %s\n", formatPath(viper.ConfigFileUsed()))
+		return nil
+	}
+	return viper.WriteConfig()
+}
+
+// userCacheSubdir 返回当前用户缓存目录（例如 ~/.cache/of）下的子目录，无法获取时使用数据目录
+// 临时文件不放在共享的 /tmp 中，其他用户无法预先放置同名的文件或符号链接
+func userCacheSubdir(name string) string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "of", name)
+	}
+	return filepath.Join(dataDir, name)
 }
 
-// addToRecentPaths 添加路径到最近使用列表
-func addToRecentPaths(path string) {
-	// 移除已存在的相同路径
-	for i, recentPath := range config.RecentPaths {
-		if recentPath == path {
-			config.RecentPaths = append(config.RecentPaths[:i], config.RecentPaths[i+1:]...)
-			break
+// configDecodeHook 解析配置时使用的解码钩子
+func configDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	return managerConfigHook(from, to, dottedKeyHook(from, to, data))
+}
+
+// dottedKeyHook 还原含点的映射键
+// viper 按 . 拆分键，application/vnd.sqlite3 之类的键读取后会变成嵌套的映射，
+// 目标是 map[string]string 时把嵌套的映射重新拼接为原来的键
+func dottedKeyHook(from, to reflect.Type, data interface{}) interface{} {
+	values, ok := data.(map[string]interface{})
+	if !ok || to.Kind() != reflect.Map || to.Elem().Kind() != reflect.String {
+		return data
+	}
+	flat := make(map[string]interface{}, len(values))
+	var flatten func(prefix string, m map[string]interface{})
+	flatten = func(prefix string, m map[string]interface{}) {
+		for key, value := range m {
+			if nested, ok := value.(map[string]interface{}); ok {
+				flatten(prefix+key+".", nested)
+				continue
+			}
+			flat[prefix+key] = value
 		}
 	}
+	flatten("", values)
+	return flat
+}
 
-	// 添加到开头
-	config.RecentPaths = append([]string{path}, config.RecentPaths...)
+// removeConfigKeys 从配置文件中删除顶层键，只删除这些键所在的行，其余内容原样保留
+func removeConfigKeys(keys ...string) error {
+	configFile := viper.ConfigFileUsed()
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
 
-	// 限制数量
-	if len(config.RecentPaths) > config.MaxRecent {
-		config.RecentPaths = config.RecentPaths[:config.MaxRecent]
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("cannot edit a flow-style config file")
+	}
+
+	// 每个键占据从键所在行到下一个顶层键之前的行；下一个键前面的注释和空行属于下一个键
+	lines := strings.SplitAfter(string(data), "\n")
+	removed := make([]bool, len(lines))
+	changed := false
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !containsString(keys, mapping.Content[i].Value) {
+			continue
+		}
+		start := mapping.Content[i].Line - 1
+		// 紧挨在键上面的注释也属于这个键（第一个键上面的注释通常是文件头，保留）
+		for i > 0 && start > 0 && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		end := len(lines)
+		if i+2 < len(mapping.Content) {
+			end = mapping.Content[i+2].Line - 1
+			for end > start+1 && isBlankOrComment(lines[end-1]) {
+				end--
+			}
+		}
+		for l := start; l < end; l++ {
+			removed[l] = true
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
 	}
 
-	// 保存配置
-	viper.Set("recent_paths", config.RecentPaths)
-	if err := viper.WriteConfig(); err != nil && debug {
-		fmt.Printf("⚠️ Warning: cannot save recent paths: %v\n", err)
+	var b strings.Builder
+	for i, line := range lines {
+		if !removed[i] {
+			b.WriteString(line)
+		}
+	}
+	if err := os.WriteFile(configFile, []byte(b.String()), info.Mode().Perm()); err != nil {
+		return err
 	}
+
+	// 重新读取，避免之后的 WriteConfig 把已删除的键写回去
+	return viper.ReadInConfig()
+}
+
+// isBlankOrComment 判断 YAML 行是否为空行或顶格的注释
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(line, "#")
 }
 
 // getCustomManager 获取自定义文件管理器命令
 func getCustomManager(managerName string) (string, bool) {
-	if cmd, exists := config.CustomManagers[managerName]; exists {
-		return cmd, true
+	if m, exists := config.CustomManagers[managerName]; exists {
+		return m.Command, true
 	}
 	return "", false
 }
@@ -374,6 +766,18 @@ func formatPath(path string) string {
 	return path
 }
 
+// expandHome 展开路径开头的 ~
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
 // getFileExtension 获取文件扩展名
 func getFileExtension(path string) string {
 	ext := filepath.Ext(path)
@@ -386,6 +790,10 @@ func getFileExtension(path string) string {
 
 // isFile 检查路径是否为文件
 func isFile(path string) bool {
+	// --dry-run 时历史版本不写入磁盘，仍然按文件处理
+	if _, ok := revSources[path]; ok {
+		return true
+	}
 	info, err := os.Stat(path)
 	if err != nil {
 		return false
@@ -393,23 +801,74 @@ func isFile(path string) bool {
 	return !info.IsDir()
 }
 
-// getAppForFileType 根据文件类型获取对应的应用程序
+// getAppForPath 返回打开路径使用的应用程序，文件按类型匹配，文件夹按 directory_rules 匹配
+// 没有命中时返回空字符串，使用文件管理器
+func getAppForPath(path string) string {
+	if isDirectory(path) {
+		return getAppForDirectory(path)
+	}
+	return getAppForFileType(path)
+}
+
+// getAppForFileType 根据文件获取对应的应用程序
+// 匹配顺序：rules 中第一条命中的规则 -> file_type_apps（扩展名 -> 文件类型组 -> 精确的 MIME 类型 -> MIME 通配符）
 func getAppForFileType(filePath string) string {
 	if !isFile(filePath) {
 		return "" // 文件夹使用默认文件管理器
 	}
 
-	ext := getFileExtension(filePath)
-	if ext == "" {
-		return "" // 没有扩展名的文件使用默认程序
+	if i, rule := matchRule(filePath); i >= 0 {
+		if debug {
+			fmt.Printf("🔍 Matched %s: %s -> %s\n", rule.label(i), rule.conditions(), rule.App)
+		}
+		return rule.App
 	}
 
-	// 从配置中获取应用程序
-	if app, exists := config.FileTypeApps[ext]; exists {
-		return app
+	_, app := matchFileTypeApp(filePath)
+	return app
+}
+
+// matchFileTypeApp 返回命中的 file_type_apps 键和对应的应用程序，没有命中时都为空
+func matchFileTypeApp(filePath string) (string, string) {
+	if !isFile(filePath) {
+		return "", "" // 文件夹使用默认文件管理器
+	}
+
+	// 从配置中按扩展名获取应用程序，扩展名单独的映射优先于文件类型组
+	if ext := getFileExtension(filePath); ext != "" {
+		if app, exists := config.FileTypeApps[ext]; exists {
+			return ext, app
+		}
+		if key, app := matchFileGroupApp(ext); key != "" {
+			return key, app
+		}
 	}
 
-	return "" // 没有配置的文件类型使用默认程序
+	// 没有扩展名或扩展名未配置时，按 MIME 类型匹配
+	mimeType := detectMIME(filePath)
+	if debug {
+		fmt.Printf("🔍 Detected MIME type: %s\n", mimeType)
+	}
+
+	if app, exists := config.FileTypeApps[mimeType]; exists {
+		return mimeType, app
+	}
+
+	// 通配符匹配，更具体的模式优先（image/* 优先于 */*）
+	bestPattern := ""
+	for key := range config.FileTypeApps {
+		if key == mimeType || !isMIMEKey(key) || !matchMIME(key, mimeType) {
+			continue
+		}
+		if len(key) > len(bestPattern) {
+			bestPattern = key
+		}
+	}
+	if bestPattern != "" {
+		return bestPattern, config.FileTypeApps[bestPattern]
+	}
+
+	return "", "" // 没有配置的文件类型使用默认程序
 }
 
 // findSimilarApp 查找相似的应用程序
@@ -584,75 +1043,156 @@ func validateApp(appName string) (bool, string) {
 		if _, err := exec.LookPath(appName); err == nil {
 			return true, appName
 		}
-		return false, fmt.Sprintf("Application '%s' does not exist in PATH", appName)
+		// 应用名称或桌面 ID 对应的 .desktop 条目
+		if entry, ok := findDesktopEntry(appName, ""); ok {
+			return true, entry.ID
+		}
+		return false, fmt.Sprintf("Application '%s' does not exist in PATH or as a desktop entry", appName)
 	}
 }
 
+// validateAppTemplate 验证应用程序或命令模板中的程序是否存在
+// 已配置的自定义管理器名称视为有效
+func validateAppTemplate(app string) (bool, string) {
+	if _, exists := getCustomManager(app); exists {
+		return true, app
+	}
+	if isCommandTemplate(app) {
+		if _, err := splitCommandLine(app); err != nil {
+			return false, err.Error()
+		}
+		return validateApp(commandProgram(app))
+	}
+	return validateApp(app)
+}
+
 // openFileWithApp 使用指定应用程序打开文件
+// appName 可以是自定义管理器名称、命令模板（例如 "code --reuse-window {path}"）或应用名称
 func openFileWithApp(filePath string, appName string) error {
+	errs := openFilesWithApp([]templateContext{{Path: filePath}}, appName)
+	return errs[filePath]
+}
+
+// openFilesWithApp 使用指定应用程序打开多个文件，应用支持时只启动一次
+// 返回每个文件对应的错误（成功时为 nil）
+func openFilesWithApp(targets []templateContext, appName string) map[string]error {
 	if debug {
-		fmt.Printf("🔍 Opening file with app: %s -> %s\n", filePath, appName)
+		files := make([]string, 0, len(targets))
+		for _, t := range targets {
+			files = append(files, formatLocation(t))
+		}
+		fmt.Printf("🔍 Opening %d file(s) with app: %s -> %s\n", len(targets), appName, strings.Join(files, ", "))
 	}
 
-	var cmd *exec.Cmd
+	errs := make(map[string]error, len(targets))
+	launches, err := resolveAppCommands(appName, targets)
+	if err != nil {
+		for _, t := range targets {
+			errs[t.Path] = err
+		}
+		return errs
+	}
 
-	switch runtime.GOOS {
-	case "darwin":
-		// macOS 使用 open -a 命令
-		cmd = exec.Command("open", "-a", appName, filePath)
-	case "windows":
-		// Windows 使用 start 命令
-		cmd = exec.Command("start", appName, filePath)
-	default:
-		// Linux 和其他系统，尝试使用自定义管理器
-		if customCmd, exists := getCustomManager(appName); exists {
-			if debug {
-				fmt.Printf("🔍 Using custom app: %s -> %s\n", appName, customCmd)
-			}
-			cmd = exec.Command(customCmd, filePath)
-		} else {
-			// 使用默认文件管理器
-			return openInFileManager(filePath)
+	// 无法解析应用时使用默认文件管理器
+	if launches == nil {
+		for _, t := range targets {
+			errs[t.Path] = openInFileManager(t.Path)
 		}
+		return errs
 	}
 
-	return cmd.Run()
+	for _, launch := range launches {
+		err := launcher.Launch(launch.Command)
+		for _, path := range launch.Paths {
+			errs[path] = err
+		}
+	}
+	return errs
 }
 
-// copyToClipboardPath 将路径复制到剪切板
-// 支持以下平台：
-// - macOS: 使用 pbcopy
-// - Windows: 使用 clip.exe
-// - Linux: 优先使用 xclip，如果不可用则使用 xsel
-func copyToClipboardPath(path string) error {
-	switch runtime.GOOS {
-	case "darwin":
-		// macOS - 使用 pbcopy
-		cmd := exec.Command("pbcopy")
-		cmd.Stdin = strings.NewReader(path)
-		return cmd.Run()
-	case "windows":
-		// Windows - 使用 clip.exe
-		cmd := exec.Command("clip.exe")
-		cmd.Stdin = strings.NewReader(path)
-		return cmd.Run()
-	case "linux":
-		// Linux - 尝试使用 xclip，如果失败则尝试 xsel
-		cmd := exec.Command("xclip", "-selection", "clipboard")
-		cmd.Stdin = strings.NewReader(path)
-		if err := cmd.Run(); err == nil {
-			return nil
+// resolveAppCommands 解析用指定应用打开目标所需的命令
+// 应用能一次接收多个文件时合并为一条命令，否则每个文件一条；
+// 无法解析应用时返回 nil，由调用方退回到文件管理器
+func resolveAppCommands(appName string, targets []templateContext) ([]appLaunch, error) {
+	paths := make([]string, 0, len(targets))
+	hasLine := false
+	for _, t := range targets {
+		paths = append(paths, t.Path)
+		hasLine = hasLine || t.Line > 0
+	}
+
+	// 有行号时按编辑器的参数约定跳转到指定位置
+	expand := func(tmpl string) ([]appLaunch, error) {
+		if hasLine {
+			if withArgs, ok := withEditorArgs(tmpl); ok {
+				tmpl = withArgs
+			}
 		}
+		if isTerminalManager(appName) {
+			return terminalLaunches(expandCommandTargets(tmpl, targets))
+		}
+		return detachLaunches(expandCommandTargets(tmpl, targets))
+	}
 
-		// 如果 xclip 失败，尝试 xsel
-		cmd = exec.Command("xsel", "--input", "--clipboard")
-		cmd.Stdin = strings.NewReader(path)
-		if err := cmd.Run(); err == nil {
-			return nil
+	// 自定义管理器
+	if customCmd, exists := getCustomManager(appName); exists {
+		if debug {
+			fmt.Printf("🔍 Using custom app: %s -> %s\n", appName, customCmd)
 		}
+		return expand(customCmd)
+	}
 
-		return fmt.Errorf("⚠️ neither xclip nor xsel is available on this Linux system")
-	default:
-		return fmt.Errorf("⚠️ unsupported operating system: %s", runtime.GOOS)
+	// 直接配置的命令模板
+	if isCommandTemplate(appName) {
+		return expand(appName)
+	}
+
+	// Linux 上按应用名称或桌面 ID 查找 .desktop 条目，直接执行其 Exec= 命令
+	if isFreedesktop() {
+		mimeType := "x-scheme-handler/" + uriScheme(targets[0].URL)
+		if targets[0].URL == "" {
+			mimeType = detectMIME(paths[0])
+		}
+		if entry, ok := findDesktopEntry(appName, mimeType); ok {
+			if debug {
+				fmt.Printf("🔍 Using desktop entry: %s (%s)\n", entry.ID, entry.Exec)
+			}
+			if hasLine {
+				if program := commandProgram(entry.Exec); program != "" {
+					if withArgs, ok := withEditorArgs(quoteCommandField(program)); ok {
+						return detachLaunches(expandCommandTargets(withArgs, targets))
+					}
+				}
+			}
+			if entry.Terminal {
+				return terminalLaunches(desktopCommands(entry, paths))
+			}
+			return detachLaunches(desktopCommands(entry, paths))
+		}
+	}
+
+	// 应用是 PATH 中已知的编辑器时直接带上位置参数执行
+	if hasLine && isCommandAvailable(appName) {
+		if withArgs, ok := withEditorArgs(quoteCommandField(appName)); ok {
+			return detachLaunches(expandCommandTargets(withArgs, targets))
+		}
+	}
+
+	opener, err := selectOpenerFor(canOpenWithApp)
+	if err != nil {
+		return nil, err
+	}
+
+	// macOS (open -a) 和 Windows (start) 支持直接指定应用
+	// 这些命令本身很快返回，同步执行以便得到错误信息；没有图形界面时跳过
+	if launches, ok := opener.OpenWithCommand(appName, paths); ok && !useHeadlessFallback() {
+		return launches, nil
 	}
+
+	// 应用是 PATH 中的命令时直接执行
+	if isCommandAvailable(appName) {
+		return detachLaunches([]appLaunch{{Command: Command{Name: appName, Args: paths}, Paths: paths}}, nil)
+	}
+
+	return nil, nil
 }
@@ -0,0 +1,470 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// appRule rules 列表中的一条规则，所有指定的条件都满足时命中，按顺序第一条命中的规则生效：
+//
+//	rules:
+//	  - name: go tests
+//	    glob: "*_test.go"
+//	    app: goland
+//	  - glob: "~/notes/**"
+//	    app: obsidian
+//	  - ext: [go, mod]
+//	    markers: [go.mod]
+//	    app: code
+//	  - mime: "image/*"
+//	    size: ">20MB"
+//	    app: gimp
+//
+// 没有规则命中时再按 file_type_apps 匹配（优先级最低）。
+//
+// directory_rules 使用相同的格式决定打开文件夹的应用（只支持 glob、regex 和 markers），
+// 没有命中时使用文件管理器：
+//
+//	directory_rules:
+//	  - markers: [go.mod, Cargo.toml, package.json]
+//	    app: code
+//	  - markers: ["*.xcodeproj"]
+//	    app: xcode
+//	  - markers: [.obsidian]
+//	    app: obsidian
+type appRule struct {
+	Name    string   `mapstructure:"name"`
+	Glob    []string `mapstructure:"glob"`    // 不含 / 时匹配文件名，否则匹配完整路径（支持 ~ 和 **）
+	Regex   string   `mapstructure:"regex"`   // 匹配完整路径（使用 /）
+	Ext     []string `mapstructure:"ext"`     // 扩展名，不含点；group:<name> 引用文件类型组
+	MIME    []string `mapstructure:"mime"`    // MIME 类型，支持 text/* 通配符
+	Size    string   `mapstructure:"size"`    // 大小范围：>10MB、<=1KB、1KB-1MB
+	Markers []string `mapstructure:"markers"` // 所在目录或上级目录中存在的文件（例如 go.mod、*.xcodeproj）
+	App     string   `mapstructure:"app"`
+
+	compiled *compiledRule // 加载配置时编译的条件，见 compileRules
+}
+
+// compiledRule 规则中预先编译的 glob、regex 和大小范围
+type compiledRule struct {
+	globs   []ruleGlob
+	regex   *regexp.Regexp
+	minSize int64
+	maxSize int64 // -1 表示没有上限
+	err     error // 条件无效时的错误，规则不会命中
+}
+
+// ruleGlob 编译后的 glob，fullPath 表示匹配完整路径而不是文件名
+type ruleGlob struct {
+	re       *regexp.Regexp
+	fullPath bool
+}
+
+// sizeUnits 大小单位，按 1024 换算
+var sizeUnits = map[string]int64{"": 1, "b": 1, "k": 1 << 10, "kb": 1 << 10, "m": 1 << 20, "mb": 1 << 20, "g": 1 << 30, "gb": 1 << 30}
+
+// sizePattern 匹配带单位的大小，例如 10MB
+var sizePattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([a-zA-Z]*)\s*$`)
+
+// defaultMarkerDepth 查找标记文件时默认最多向上查找的层数（marker_depth）
+const defaultMarkerDepth = 5
+
+// plainDirectories --plain：文件夹始终使用文件管理器，忽略 directory_rules
+var plainDirectories bool
+
+// markerDepth 返回查找标记文件时最多向上查找的层数
+func markerDepth() int {
+	if config.MarkerDepth < 0 {
+		return 0
+	}
+	return config.MarkerDepth
+}
+
+// label 返回规则的显示名称，编号从 1 开始，与 config list-rules 一致
+func (r appRule) label(index int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("rule %d (%s)", index+1, r.Name)
+	}
+	return fmt.Sprintf("rule %d", index+1)
+}
+
+// conditions 返回规则条件的简短描述
+func (r appRule) conditions() string {
+	var parts []string
+	if len(r.Glob) > 0 {
+		parts = append(parts, "glob="+strings.Join(r.Glob, ","))
+	}
+	if r.Regex != "" {
+		parts = append(parts, "regex="+r.Regex)
+	}
+	if len(r.Ext) > 0 {
+		parts = append(parts, "ext="+strings.Join(r.Ext, ","))
+	}
+	if len(r.MIME) > 0 {
+		parts = append(parts, "mime="+strings.Join(r.MIME, ","))
+	}
+	if r.Size != "" {
+		parts = append(parts, "size="+r.Size)
+	}
+	if len(r.Markers) > 0 {
+		parts = append(parts, "markers="+strings.Join(r.Markers, ","))
+	}
+	if len(parts) == 0 {
+		return "(always)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// compileRule 编译规则的条件
+func compileRule(r appRule) *compiledRule {
+	c := &compiledRule{maxSize: -1}
+	for _, g := range r.Glob {
+		re, err := globToRegexp(g)
+		if err != nil {
+			c.err = fmt.Errorf("invalid glob %q: %v", g, err)
+			return c
+		}
+		c.globs = append(c.globs, ruleGlob{re: re, fullPath: strings.Contains(g, "/")})
+	}
+	if r.Regex != "" {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			c.err = fmt.Errorf("invalid regex %q: %v", r.Regex, err)
+			return c
+		}
+		c.regex = re
+	}
+	if r.Size != "" {
+		c.minSize, c.maxSize, c.err = parseSizeRange(r.Size)
+	}
+	return c
+}
+
+// compileRules 加载配置后编译所有规则，匹配时不再重复编译
+func compileRules() {
+	for i := range config.Rules {
+		config.Rules[i].compiled = compileRule(config.Rules[i])
+	}
+	for i := range config.DirectoryRules {
+		config.DirectoryRules[i].compiled = compileRule(config.DirectoryRules[i])
+	}
+}
+
+// patterns 返回编译后的条件，没有预先编译的规则（例如刚通过 add-rule 添加）在这里编译
+func (r appRule) patterns() *compiledRule {
+	if r.compiled != nil {
+		return r.compiled
+	}
+	return compileRule(r)
+}
+
+// validate 检查规则是否有效
+func (r appRule) validate() error {
+	if r.App == "" {
+		return fmt.Errorf("rule has no app")
+	}
+	return r.patterns().err
+}
+
+// validateDirectory 检查规则能否用于文件夹，文件夹只支持 glob、regex 和 markers
+func (r appRule) validateDirectory() error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if len(r.Ext) > 0 || len(r.MIME) > 0 || r.Size != "" {
+		return fmt.Errorf("directory rules only support glob, regex and markers")
+	}
+	return nil
+}
+
+// matches 判断文件是否满足规则的所有条件
+// mimeType 延迟计算，只有规则使用 MIME 条件时才检测。
+// 历史版本的临时文件按仓库中的原路径匹配 glob、regex 和 markers，大小和 MIME 按文件内容判断
+func (r appRule) matches(path string, mimeType func() string) (bool, error) {
+	c := r.patterns()
+	if c.err != nil {
+		return false, c.err
+	}
+	source := revSourcePath(path)
+	slashPath := filepath.ToSlash(source)
+
+	if len(r.Ext) > 0 {
+		ext := getFileExtension(path)
+		matched := false
+		for _, e := range r.Ext {
+			if extensionMatches(e, ext) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if len(c.globs) > 0 {
+		matched := false
+		for _, g := range c.globs {
+			target := filepath.Base(source)
+			if g.fullPath {
+				target = slashPath
+			}
+			if g.re.MatchString(target) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if c.regex != nil && !c.regex.MatchString(slashPath) {
+		return false, nil
+	}
+
+	if r.Size != "" {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() < c.minSize || (c.maxSize >= 0 && info.Size() > c.maxSize) {
+			return false, nil
+		}
+	}
+
+	if len(r.MIME) > 0 {
+		detected := mimeType()
+		matched := false
+		for _, m := range r.MIME {
+			if matchMIME(strings.ToLower(m), detected) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if len(r.Markers) > 0 {
+		dir := source
+		if !isDirectory(source) {
+			dir = filepath.Dir(source)
+		}
+		if _, found := findMarker(dir, r.Markers, markerDepth()); !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// matchRule 返回第一条命中的规则，没有命中时返回 -1
+func matchRule(path string) (int, appRule) {
+	mimeType := ""
+	detect := func() string {
+		if mimeType == "" {
+			mimeType = detectMIME(path)
+		}
+		return mimeType
+	}
+
+	for i, rule := range config.Rules {
+		if rule.App == "" {
+			continue
+		}
+		matched, err := rule.matches(path, detect)
+		if err != nil {
+			if debug {
+				fmt.Printf("🔍 Skipping %s: %v\n", rule.label(i), err)
+			}
+			continue
+		}
+		if matched {
+			return i, rule
+		}
+	}
+	return -1, appRule{}
+}
+
+// matchDirectoryRule 返回第一条命中的目录规则，没有命中时返回 -1
+// 标记从文件夹本身开始向上查找，例如在 Go 模块的子目录中运行 of . 也能命中 go.mod
+func matchDirectoryRule(dir string) (int, appRule) {
+	for i, rule := range config.DirectoryRules {
+		if err := rule.validateDirectory(); err != nil {
+			if debug {
+				fmt.Printf("🔍 Skipping directory %s: %v\n", rule.label(i), err)
+			}
+			continue
+		}
+		if matched, _ := rule.matches(dir, func() string { return "" }); matched {
+			return i, rule
+		}
+	}
+	return -1, appRule{}
+}
+
+// getAppForDirectory 根据 directory_rules 获取打开文件夹的应用程序
+// 使用 --plain、通过 -m 指定了管理器或没有规则命中时返回空字符串，使用文件管理器
+func getAppForDirectory(dir string) string {
+	if plainDirectories || explicitManager() {
+		return ""
+	}
+	if i, rule := matchDirectoryRule(dir); i >= 0 {
+		if debug {
+			fmt.Printf("🔍 Matched directory %s: %s -> %s\n", rule.label(i), rule.conditions(), rule.App)
+		}
+		return rule.App
+	}
+	return ""
+}
+
+// findMarker 从 dir 开始向上查找标记文件，depth 为最多向上查找的层数（0 只查找 dir 本身）
+// 标记支持通配符，例如 *.xcodeproj；返回找到标记的目录
+func findMarker(dir string, markers []string, depth int) (string, bool) {
+	dir = filepath.Clean(dir)
+	for level := 0; level <= depth; level++ {
+		for _, marker := range markers {
+			if hasGlobMeta(marker) {
+				if matches, _ := filepath.Glob(filepath.Join(dir, marker)); len(matches) > 0 {
+					return dir, true
+				}
+			} else if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", false
+}
+
+// globToRegexp 把 glob 模式转换为正则表达式
+// * 和 ? 不匹配 /，** 匹配任意层目录，开头的 ~ 展开为主目录
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	glob = filepath.ToSlash(expandHome(glob))
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// parseSizeRange 解析大小范围，返回最小值和最大值（没有上限时为 -1）
+// 支持 >N、>=N、<N、<=N、A-B，单独的 N 表示至少 N
+func parseSizeRange(s string) (int64, int64, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid size range: %q (e.g. >10MB, <1KB, 1KB-1MB)", s)
+
+	parse := func(v string) (int64, error) {
+		m := sizePattern.FindStringSubmatch(v)
+		if m == nil {
+			return 0, invalid
+		}
+		unit, exists := sizeUnits[strings.ToLower(m[2])]
+		if !exists {
+			return 0, invalid
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, invalid
+		}
+		return int64(n * float64(unit)), nil
+	}
+
+	switch {
+	case strings.HasPrefix(s, ">="):
+		n, err := parse(s[2:])
+		return n, -1, err
+	case strings.HasPrefix(s, ">"):
+		n, err := parse(s[1:])
+		return n + 1, -1, err
+	case strings.HasPrefix(s, "<="):
+		n, err := parse(s[2:])
+		return 0, n, err
+	case strings.HasPrefix(s, "<"):
+		n, err := parse(s[1:])
+		return 0, n - 1, err
+	case strings.Contains(s, "-"):
+		lo, hi, _ := strings.Cut(s, "-")
+		min, err := parse(lo)
+		if err != nil {
+			return 0, 0, err
+		}
+		max, err := parse(hi)
+		if err != nil {
+			return 0, 0, err
+		}
+		if max < min {
+			return 0, 0, invalid
+		}
+		return min, max, nil
+	default:
+		n, err := parse(s)
+		return n, -1, err
+	}
+}
+
+// ruleConfigValues 返回用于写回配置文件的 rules，省略空字段
+func ruleConfigValues(rules []appRule) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(rules))
+	for _, r := range rules {
+		v := map[string]interface{}{"app": r.App}
+		if r.Name != "" {
+			v["name"] = r.Name
+		}
+		if len(r.Glob) > 0 {
+			v["glob"] = r.Glob
+		}
+		if r.Regex != "" {
+			v["regex"] = r.Regex
+		}
+		if len(r.Ext) > 0 {
+			v["ext"] = r.Ext
+		}
+		if len(r.MIME) > 0 {
+			v["mime"] = r.MIME
+		}
+		if r.Size != "" {
+			v["size"] = r.Size
+		}
+		if len(r.Markers) > 0 {
+			v["markers"] = r.Markers
+		}
+		values = append(values, v)
+	}
+	return values
+}
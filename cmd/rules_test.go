@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths in this test use /")
+	}
+	t.Setenv("HOME", "/home/me")
+
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.golang", false},
+		{"*.go", "cmd/main.go", false},
+		{"*_test.go", "root_test.go", true},
+		{"?.txt", "a.txt", true},
+		{"?.txt", "ab.txt", false},
+		{"[abc].txt", "b.txt", true},
+		{"[!abc].txt", "b.txt", false},
+		{"[!abc].txt", "d.txt", true},
+		{"a+b(1).txt", "a+b(1).txt", true},
+		{"a.txt", "abtxt", false},
+		{"/src/*.go", "/src/main.go", true},
+		{"/src/*.go", "/src/pkg/main.go", false},
+		{"/src/**/*.go", "/src/main.go", true},
+		{"/src/**/*.go", "/src/a/b/main.go", true},
+		{"/src/**", "/src/a/b/c", true},
+		{"/src/**", "/other/a", false},
+		{"**/vendor/**", "/repo/vendor/x/y.go", true},
+		{"~/notes/**", "/home/me/notes/a/b.md", true},
+		{"~/notes/**", "/home/other/notes/a.md", false},
+	}
+
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Errorf("globToRegexp(%q): %v", tt.glob, err)
+			continue
+		}
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("globToRegexp(%q) matches %q = %v, want %v", tt.glob, tt.path, got, tt.match)
+		}
+	}
+
+	if _, err := globToRegexp("[abc.txt"); err == nil {
+		t.Error("globToRegexp(\"[abc.txt\"): expected an error")
+	}
+}
+
+func TestParseSizeRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max int64
+		wantErr  bool
+	}{
+		{in: "10", min: 10, max: -1},
+		{in: "10MB", min: 10 << 20, max: -1},
+		{in: ">10MB", min: 10<<20 + 1, max: -1},
+		{in: ">=10mb", min: 10 << 20, max: -1},
+		{in: "<1KB", min: 0, max: 1<<10 - 1},
+		{in: "<=1k", min: 0, max: 1 << 10},
+		{in: "1KB-1MB", min: 1 << 10, max: 1 << 20},
+		{in: " 1.5 GB ", min: 3 << 29, max: -1},
+		{in: "512b", min: 512, max: -1},
+		{in: "", wantErr: true},
+		{in: ">", wantErr: true},
+		{in: "10XB", wantErr: true},
+		{in: "1MB-1KB", wantErr: true},
+		{in: "1KB-", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		min, max, err := parseSizeRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSizeRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (min != tt.min || max != tt.max) {
+			t.Errorf("parseSizeRange(%q) = %d, %d; want %d, %d", tt.in, min, max, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(repo, "pkg", "main_test.go")
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, make([]byte, 2048), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 历史版本的临时文件不在仓库中，按原路径匹配 glob 和 markers
+	rev := filepath.Join(t.TempDir(), "x@abc", "pkg", "main_test.go")
+	if err := os.MkdirAll(filepath.Dir(rev), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rev, make([]byte, 2048), 0600); err != nil {
+		t.Fatal(err)
+	}
+	revSources[rev] = file
+	t.Cleanup(func() { delete(revSources, rev) })
+
+	savedDepth := config.MarkerDepth
+	config.MarkerDepth = defaultMarkerDepth
+	t.Cleanup(func() { config.MarkerDepth = savedDepth })
+
+	tests := []struct {
+		name    string
+		rule    appRule
+		match   bool
+		wantErr bool
+	}{
+		{"glob on name", appRule{Glob: []string{"*_test.go"}}, true, false},
+		{"glob on path", appRule{Glob: []string{filepath.ToSlash(repo) + "/**/*.go"}}, true, false},
+		{"glob miss", appRule{Glob: []string{"*.rs"}}, false, false},
+		{"regex", appRule{Regex: `/pkg/[^/]+_test\.go$`}, true, false},
+		{"ext", appRule{Ext: []string{".GO"}}, true, false},
+		{"ext group", appRule{Ext: []string{"group:code"}}, true, false},
+		{"size", appRule{Size: "1KB-4KB"}, true, false},
+		{"size miss", appRule{Size: ">4KB"}, false, false},
+		{"markers", appRule{Markers: []string{"go.mod"}}, true, false},
+		{"markers miss", appRule{Markers: []string{"Cargo.toml"}}, false, false},
+		{"all conditions", appRule{Glob: []string{"*.go"}, Ext: []string{"go"}, Size: "<1MB", Markers: []string{"go.mod"}}, true, false},
+		{"invalid regex", appRule{Regex: "("}, false, true},
+		{"invalid size", appRule{Size: "big"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.App = "code"
+			// 预先编译和按需编译的结果应当一致
+			compiled := tt.rule
+			compiled.compiled = compileRule(compiled)
+			for _, path := range []string{file, rev} {
+				for _, rule := range []appRule{tt.rule, compiled} {
+					matched, err := rule.matches(path, func() string { return "text/x-go" })
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("matches(%s) error = %v, wantErr %v", path, err, tt.wantErr)
+					}
+					if matched != tt.match {
+						t.Errorf("matches(%s) = %v, want %v", path, matched, tt.match)
+					}
+				}
+			}
+		})
+	}
+}
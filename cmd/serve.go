@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serveListen string
+
+// defaultRemoteMaxAgeHours 收到的文件的默认保留时间（小时）
+const defaultRemoteMaxAgeHours = 24
+
+// maxRemoteConns 同时处理的连接数上限，超出时直接关闭新连接
+const maxRemoteConns = 16
+
+// remoteMu 打开操作会修改全局状态（启动器、管理器），请求逐个执行；
+// 读取和验证请求在各自的 goroutine 中进行，慢速连接不会阻塞其他客户端
+var remoteMu sync.Mutex
+
+// safeRemoteExtensions 远程发送的文件中允许直接打开的类型：文档、图片、音视频和压缩包
+// 其他类型（脚本、安装包、启动器、磁盘映像、带宏的 Office 文档以及没有扩展名的文件）
+// 双击可能执行代码，只能用 --reveal 在文件管理器中查看
+var safeRemoteExtensions = []string{
+	// 文本和源代码
+	"txt", "text", "md", "markdown", "rst", "adoc", "org", "log", "csv", "tsv", "json", "jsonl", "yaml", "yml", "toml", "ini", "conf", "cfg", "xml", "diff", "patch", "tex", "bib", "srt", "vtt",
+	"go", "rs", "c", "h", "cc", "cpp", "hpp", "java", "kt", "swift", "cs", "ts", "tsx", "jsx", "css", "scss", "sql", "proto", "html", "htm",
+	// 文档
+	"pdf", "epub", "djvu", "rtf", "odt", "ods", "odp", "odg", "docx", "xlsx", "pptx", "pages", "numbers", "key",
+	// 图片
+	"png", "jpg", "jpeg", "gif", "webp", "bmp", "tif", "tiff", "heic", "heif", "avif", "ico", "svg", "psd",
+	// 音频和视频
+	"mp3", "wav", "flac", "ogg", "oga", "opus", "m4a", "aac", "aiff", "mp4", "m4v", "mkv", "webm", "mov", "avi", "wmv", "mpg", "mpeg",
+	// 压缩包
+	"zip", "tar", "gz", "tgz", "bz2", "xz", "zst", "7z",
+}
+
+// allowedRemoteSchemes 远程请求中允许打开的 URL scheme（remote_url 使用的网络文件系统和网页），
+// scheme_handlers 中配置的 scheme 也允许
+var allowedRemoteSchemes = []string{"sftp", "ssh", "smb", "afp", "nfs", "ftp", "ftps", "dav", "davs", "webdav", "http", "https"}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "open files sent from remote shells on this machine",
+	Long: `Listen for open/reveal/copy requests from "of --remote" and handle them
+on this machine with the same logic as a local "of".
+
+Clients must send the shared token (serve_token in config, generated on first
+run, or OF_REMOTE_TOKEN). Files are transferred by content and opened from a
+temporary directory; directories and large files are opened by URL
+(sftp://user@host/path by default, see remote_url).
+
+Examples:
+  of serve                              # listen on 127.0.0.1:7077
+  of serve --listen ~/.of/serve.sock    # listen on a Unix socket
+  ssh -R 7077:127.0.0.1:7077 devbox     # then on devbox: of --remote file.log`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+
+		// 第一次运行时生成令牌并保存，只在生成时显示一次
+		newToken := config.ServeToken == ""
+		if newToken {
+			token, err := generateToken()
+			if err != nil {
+				fmt.Printf("❌ Error: cannot generate token: %v\n", err)
+				os.Exit(1)
+			}
+			config.ServeToken = token
+			viper.Set("serve_token", token)
+			if err := writeConfig(); err != nil {
+				fmt.Printf("❌ Error saving config: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		addr := serveListen
+		if addr == "" {
+			addr = config.ServeListen
+		}
+		if addr == "" {
+			addr = defaultRemoteAddress
+		}
+		network, address := parseRemoteAddress(addr)
+		if network == "unix" {
+			address = expandHome(address)
+			if err := removeStaleSocket(address); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// socket 创建时就只有当前用户可以连接，不留下可以被其他用户连接的时间窗口
+		restore := func() {}
+		if network == "unix" {
+			restore = restrictUmask()
+		}
+		listener, err := net.Listen(network, address)
+		restore()
+		if err != nil {
+			fmt.Printf("❌ Error: cannot listen on %s: %v\n", address, err)
+			os.Exit(1)
+		}
+
+		// Ctrl-C 时关闭监听，Unix socket 文件会被自动删除
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			listener.Close()
+		}()
+
+		useDefaultManager()
+		fmt.Printf("📡 Listening on %s:%s\n", network, address)
+		switch {
+		case newToken && dryRun:
+			fmt.Printf("🔑 Generated token for this run (not saved, --dry-run): %s\n", config.ServeToken)
+		case newToken:
+			fmt.Printf("🔑 Generated token (saved as serve_token): %s\n", config.ServeToken)
+		default:
+			fmt.Printf("🔑 Using serve_token from %s\n", formatPath(viper.ConfigFileUsed()))
+		}
+
+		slots := make(chan struct{}, maxRemoteConns)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if debug {
+					fmt.Printf("🔍 Listener closed: %v\n", err)
+				}
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+				go func() {
+					defer func() { <-slots }()
+					handleRemoteConn(conn)
+				}()
+			default:
+				if debug {
+					fmt.Printf("🔍 Too many connections, dropping %s\n", conn.RemoteAddr())
+				}
+				conn.Close()
+			}
+		}
+	},
+}
+
+// removeStaleSocket 清理上次异常退出留下的 socket
+// 路径上已有的普通文件、目录等不是 socket 的内容不会被删除
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s already exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+// handleRemoteConn 处理一个连接上的请求
+func handleRemoteConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(remoteTimeout))
+
+	var req remoteRequest
+	resp := remoteResponse{}
+	if err := json.NewDecoder(io.LimitReader(conn, maxRemoteRequest)).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %v", err)
+	} else if subtle.ConstantTimeCompare([]byte(req.Token), []byte(config.ServeToken)) != 1 {
+		resp.Error = "invalid token"
+		fmt.Printf("⛔ Rejected request from %s: invalid token\n", conn.RemoteAddr())
+	} else {
+		remoteMu.Lock()
+		app, err := handleRemoteRequest(req)
+		remoteMu.Unlock()
+		if err != nil {
+			resp.Error = err.Error()
+			fmt.Printf("❌ %s %s:%s: %v\n", req.Action, req.Host, req.Path, err)
+		} else {
+			resp.OK, resp.App = true, app
+			fmt.Printf("📥 %s %s:%s -> %s\n", req.Action, req.Host, req.Path, app)
+		}
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// handleRemoteRequest 在本机执行请求，返回使用的应用
+func handleRemoteRequest(req remoteRequest) (string, error) {
+	if req.Action == actionCopy {
+		text := req.Path
+		if req.URL != "" {
+			text = req.URL
+		}
+		return "clipboard", copyToClipboardPath(text)
+	}
+
+	var target templateContext
+	switch {
+	case req.Content != "":
+		local, err := saveRemoteFile(req)
+		if err != nil {
+			return "", err
+		}
+		target = templateContext{Path: local, Line: req.Line, Col: req.Col}
+	case req.URL != "":
+		scheme := uriScheme(req.URL)
+		if _, handled := config.SchemeHandlers[scheme]; !handled && !containsString(allowedRemoteSchemes, scheme) {
+			return "", fmt.Errorf("unsupported URL: %s", req.URL)
+		}
+		target = templateContext{Path: req.URL, URL: req.URL}
+	default:
+		return "", fmt.Errorf("request has neither content nor URL")
+	}
+
+	switch req.Action {
+	case actionReveal:
+		if target.URL != "" {
+			return "", fmt.Errorf("cannot reveal a URL: %s", target.URL)
+		}
+		return fileManagerLabel(), revealPath(target.Path)
+	case actionOpen:
+		if target.URL == "" && isBlockedRemoteFile(target.Path) {
+			return "", fmt.Errorf("refusing to open %s: only document and media files are opened, other types are only revealed (use --reveal)", filepath.Base(target.Path))
+		}
+		r := openPaths([]templateContext{target})[0]
+		return r.App, r.Err
+	default:
+		return "", fmt.Errorf("unknown action: %s", req.Action)
+	}
+}
+
+// saveRemoteFile 把收到的文件内容保存到临时目录，按来源主机分开存放
+// 超过 remote_max_age_hours 的旧文件会在保存前被清理
+func saveRemoteFile(req remoteRequest) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return "", fmt.Errorf("invalid content: %v", err)
+	}
+
+	// 只使用文件名，防止路径穿越；去掉 Windows 会忽略的结尾点和空格，: 会被当作 NTFS 数据流
+	name := filepath.Base(filepath.FromSlash(strings.ReplaceAll(req.Name, `\`, "/")))
+	name = strings.TrimRight(strings.ReplaceAll(name, ":", "_"), ". ")
+	if name == "." || name == ".." || name == string(filepath.Separator) || name == "" {
+		name = "remote-file"
+	}
+	host := filepath.Base(req.Host)
+	if host == "." || host == ".." || host == string(filepath.Separator) || host == "" {
+		host = "unknown"
+	}
+
+	removeOldFiles(remoteTempDir(), config.RemoteMaxAgeHours)
+
+	dir := filepath.Join(remoteTempDir(), host)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	// 同名文件再次发送时覆盖；先删除再以 O_EXCL 创建，不会写到预先放置的符号链接指向的文件
+	local := filepath.Join(dir, name)
+	if err := os.RemoveAll(local); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(local)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(local)
+		return "", err
+	}
+	return local, nil
+}
+
+// remoteTempDir 返回保存远程文件的目录
+func remoteTempDir() string {
+	return userCacheSubdir("remote")
+}
+
+// isBlockedRemoteFile 判断远程发送的文件是否不在允许直接打开的类型中
+func isBlockedRemoteFile(path string) bool {
+	return !containsString(safeRemoteExtensions, getFileExtension(path))
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l", "", "address to listen on (host:port, unix:/path or socket path)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRemoveStaleSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets are not used on Windows")
+	}
+	dir := t.TempDir()
+
+	if err := removeStaleSocket(filepath.Join(dir, "missing.sock")); err != nil {
+		t.Errorf("removeStaleSocket(missing): %v", err)
+	}
+
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeStaleSocket(file); err == nil {
+		t.Error("removeStaleSocket(regular file): expected an error")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("regular file was removed: %v", err)
+	}
+
+	sock := filepath.Join(dir, "of.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("cannot create a Unix socket: %v", err)
+	}
+	// 模拟异常退出：关闭监听但保留 socket 文件
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	if err := removeStaleSocket(sock); err != nil {
+		t.Errorf("removeStaleSocket(socket): %v", err)
+	}
+	if _, err := os.Lstat(sock); !os.IsNotExist(err) {
+		t.Errorf("stale socket was not removed: %v", err)
+	}
+}
+
+func TestIsBlockedRemoteFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocked bool
+	}{
+		{"report.pdf", false},
+		{"photo.JPG", false},
+		{"notes.md", false},
+		{"main.go", false},
+		{"backup.tar.gz", false},
+		{"slides.pptx", false},
+		{"install.sh", true},
+		{"setup.exe", true},
+		{"tool.py", true},
+		{"script.pyw", true},
+		{"run.pl", true},
+		{"index.php", true},
+		{"budget.xlsm", true},
+		{"letter.docm", true},
+		{"help.chm", true},
+		{"disk.iso", true},
+		{"vm.vhdx", true},
+		{"app.jnlp", true},
+		{"launcher.desktop", true},
+		{"README", true},
+	}
+
+	for _, tt := range tests {
+		if got := isBlockedRemoteFile(tt.name); got != tt.blocked {
+			t.Errorf("isBlockedRemoteFile(%q) = %v, want %v", tt.name, got, tt.blocked)
+		}
+	}
+}
+
+func TestSaveRemoteFile(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the cache directory is set through XDG_CACHE_HOME")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	saved := config.RemoteMaxAgeHours
+	config.RemoteMaxAgeHours = defaultRemoteMaxAgeHours
+	t.Cleanup(func() { config.RemoteMaxAgeHours = saved })
+
+	// 超过保留时间的旧文件在保存新文件时被清理
+	old := filepath.Join(remoteTempDir(), "devbox", "old.log")
+	if err := os.MkdirAll(filepath.Dir(old), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// 预先放置的符号链接会被替换，而不是写到它指向的文件
+	target := filepath.Join(t.TempDir(), "target")
+	if err := os.WriteFile(target, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(remoteTempDir(), "devbox", "notes.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	req := remoteRequest{Host: "devbox", Name: "../notes.md", Content: base64.StdEncoding.EncodeToString([]byte("new"))}
+	local, err := saveRemoteFile(req)
+	if err != nil {
+		t.Fatalf("saveRemoteFile: %v", err)
+	}
+	if want := filepath.Join(remoteTempDir(), "devbox", "notes.md"); local != want {
+		t.Errorf("saveRemoteFile = %s, want %s", local, want)
+	}
+	if info, err := os.Lstat(local); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("saved file is not a regular file: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "keep" {
+		t.Errorf("symlink target was overwritten: %q", data)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old file was not removed: %v", err)
+	}
+}
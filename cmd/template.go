@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// 命令模板支持的占位符：
+//
+//	{path}  绝对路径
+//	{dir}   文件所在目录（路径本身是目录时为其自身）
+//	{name}  文件名（含扩展名）
+//	{ext}   扩展名（不含点号）
+//	{uri}   file:// URI
+//	{url}   打开 URL 时为原始 URL，打开文件时与 {uri} 相同
+//	{line}  行号（未知时为空）
+//	{col}   列号（未知时为空）
+//
+// 行号或列号未知时，只包含 {line}/{col} 的参数（以及紧挨在它前面的选项，
+// 例如 "--line {line}" 中的 --line）会被丢弃。
+//
+// 模板中没有任何占位符时，会自动在末尾追加 {path}，
+// 因此旧配置中的 "code" 与 "code {path}" 等价。
+var templatePlaceholders = []string{"{path}", "{dir}", "{name}", "{ext}", "{uri}", "{url}", "{line}", "{col}"}
+
+// templateContext 展开命令模板时使用的上下文
+type templateContext struct {
+	Path string
+	Line int
+	Col  int
+	URL  string // 打开 URL 时与 Path 相同，打开本地路径时为空
+}
+
+// values 返回占位符到实际值的映射
+func (t templateContext) values() map[string]string {
+	if t.URL != "" {
+		return map[string]string{
+			"{path}": t.URL,
+			"{dir}":  "",
+			"{name}": "",
+			"{ext}":  "",
+			"{uri}":  t.URL,
+			"{url}":  t.URL,
+			"{line}": "",
+			"{col}":  "",
+		}
+	}
+
+	dir := t.Path
+	if isFile(t.Path) {
+		dir = filepath.Dir(t.Path)
+	}
+
+	values := map[string]string{
+		"{path}": t.Path,
+		"{dir}":  dir,
+		"{name}": filepath.Base(t.Path),
+		"{ext}":  getFileExtension(t.Path),
+		"{uri}":  fileURI(t.Path),
+		"{url}":  fileURI(t.Path),
+		"{line}": "",
+		"{col}":  "",
+	}
+	if t.Line > 0 {
+		values["{line}"] = strconv.Itoa(t.Line)
+	}
+	if t.Col > 0 {
+		values["{col}"] = strconv.Itoa(t.Col)
+	}
+	return values
+}
+
+// hasPlaceholder 判断字符串中是否包含任意占位符
+func hasPlaceholder(s string) bool {
+	for _, p := range templatePlaceholders {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCommandTemplate 判断 file_type_apps 中的值是命令模板还是应用名称
+// 应用名称本身可能包含空格（例如 "Visual Studio Code"），因此只有包含占位符时才视为模板
+func isCommandTemplate(s string) bool {
+	return hasPlaceholder(s)
+}
+
+// expandCommand 解析命令模板并替换占位符
+func expandCommand(tmpl string, ctx templateContext) (Command, error) {
+	fields, err := splitCommandLine(tmpl)
+	if err != nil {
+		return Command{}, err
+	}
+	if len(fields) == 0 {
+		return Command{}, fmt.Errorf("⚠️ empty command template")
+	}
+
+	// 兼容旧配置：没有占位符时把路径追加到末尾
+	if !hasPlaceholder(tmpl) {
+		fields = append(fields, "{path}")
+	}
+
+	values := ctx.values()
+	argv := make([]string, 0, len(fields))
+	for i, field := range fields {
+		expanded, keep := expandField(field, values)
+		if keep {
+			argv = append(argv, expanded)
+			continue
+		}
+		// 被丢弃的位置参数前面的选项（例如 "--line {line}" 中的 --line）一并丢弃
+		if i > 0 && len(argv) > 1 && strings.HasPrefix(fields[i-1], "-") && !hasPlaceholder(fields[i-1]) {
+			argv = argv[:len(argv)-1]
+		}
+	}
+	// 所有参数都被丢弃（例如模板只有 "+{line}" 且没有行号）时没有可执行的程序
+	if len(argv) == 0 {
+		return Command{}, fmt.Errorf("⚠️ command template expands to an empty command: %s", tmpl)
+	}
+
+	if debug {
+		fmt.Printf("🔍 Expanded template: %s -> %s\n", tmpl, Command{Name: argv[0], Args: argv[1:]})
+	}
+
+	return Command{Name: argv[0], Args: argv[1:]}, nil
+}
+
+// expandCommandTargets 为多个目标展开命令模板
+// 模板中路径只以独立的 {path}、{uri} 或 {url} 参数出现时（例如 "code -r {path}"），
+// 所有目标合并为一条命令；否则每个目标单独展开一条命令
+func expandCommandTargets(tmpl string, targets []templateContext) ([]appLaunch, error) {
+	fields, err := splitCommandLine(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("⚠️ empty command template")
+	}
+
+	multi := len(targets) > 1
+	for _, field := range fields {
+		if hasPlaceholder(field) && field != "{path}" && field != "{uri}" && field != "{url}" {
+			multi = false
+		}
+	}
+
+	if multi {
+		if !hasPlaceholder(tmpl) {
+			fields = append(fields, "{path}")
+		}
+
+		var argv []string
+		paths := make([]string, 0, len(targets))
+		for _, field := range fields {
+			switch field {
+			case "{path}", "{uri}", "{url}":
+				for _, t := range targets {
+					argv = append(argv, t.values()[field])
+				}
+			default:
+				argv = append(argv, field)
+			}
+		}
+		for _, t := range targets {
+			paths = append(paths, t.Path)
+		}
+		if len(argv) == 0 {
+			return nil, fmt.Errorf("⚠️ command template expands to an empty command: %s", tmpl)
+		}
+		return []appLaunch{{Command: Command{Name: argv[0], Args: argv[1:]}, Paths: paths}}, nil
+	}
+
+	launches := make([]appLaunch, 0, len(targets))
+	for _, t := range targets {
+		cmd, err := expandCommand(tmpl, t)
+		if err != nil {
+			return nil, err
+		}
+		launches = append(launches, appLaunch{Command: cmd, Paths: []string{t.Path}})
+	}
+	return launches, nil
+}
+
+// expandField 替换单个参数中的占位符
+// 只引用了 {line}/{col} 且缺少对应值的参数（例如 "+{line}"）会被整体丢弃，
+// 而 "{path}:{line}:{col}"、"+{line},{col}" 这类参数会去掉多余的分隔符
+func expandField(field string, values map[string]string) (string, bool) {
+	if !hasPlaceholder(field) {
+		return field, true
+	}
+
+	positionOnly := true
+	for _, p := range templatePlaceholders {
+		if p != "{line}" && p != "{col}" && strings.Contains(field, p) {
+			positionOnly = false
+		}
+	}
+
+	hasLine := strings.Contains(field, "{line}")
+	missingPosition := (hasLine && values["{line}"] == "") ||
+		(strings.Contains(field, "{col}") && values["{col}"] == "")
+
+	// 只引用了列号时以列号为准，否则以行号为准
+	primary := "{line}"
+	if !hasLine {
+		primary = "{col}"
+	}
+	if positionOnly && values[primary] == "" {
+		return "", false
+	}
+
+	// 一次替换所有占位符，路径中出现的 {ext} 之类的文本不会被再次展开
+	pairs := make([]string, 0, len(templatePlaceholders)*2)
+	for _, p := range templatePlaceholders {
+		pairs = append(pairs, p, values[p])
+	}
+	result := strings.NewReplacer(pairs...).Replace(field)
+	if missingPosition {
+		result = strings.TrimRight(result, ":#,")
+	}
+	return result, true
+}
+
+// splitCommandLine 按 shell 风格拆分命令行
+// 支持单引号、双引号和反斜杠转义（Windows 下反斜杠作为路径分隔符，不做转义）
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		inField bool
+		quote   rune
+		escaped bool
+	)
+	backslashEscapes := runtime.GOOS != "windows"
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && backslashEscapes:
+				escaped = true
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inField = true
+		case r == '\\' && backslashEscapes:
+			escaped = true
+			inField = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("⚠️ unterminated quote in command: %s", s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("⚠️ trailing backslash in command: %s", s)
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+// commandProgram 返回命令模板中的可执行程序名称
+func commandProgram(tmpl string) string {
+	fields, err := splitCommandLine(tmpl)
+	if err != nil || len(fields) == 0 {
+		return tmpl
+	}
+	return fields[0]
+}
+
+// fileURI 将本地路径转换为 file:// URI
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// Windows 盘符路径，例如 C:/Users -> /C:/Users
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
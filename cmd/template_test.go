@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+		unix    bool // 反斜杠转义只在非 Windows 平台生效
+	}{
+		{name: "empty", in: ""},
+		{name: "spaces only", in: "  \t "},
+		{name: "simple", in: "code -r {path}", want: []string{"code", "-r", "{path}"}},
+		{name: "repeated spaces", in: "  code   {path}  ", want: []string{"code", "{path}"}},
+		{name: "double quotes", in: `"/Applications/My App" {path}`, want: []string{"/Applications/My App", "{path}"}},
+		{name: "single quotes", in: `sh -c 'echo "$1"' {path}`, want: []string{"sh", "-c", `echo "$1"`, "{path}"}},
+		{name: "empty quotes", in: `start "" {path}`, want: []string{"start", "", "{path}"}},
+		{name: "quotes inside field", in: `--flag="a b"`, want: []string{"--flag=a b"}},
+		{name: "unterminated quote", in: `code "{path}`, wantErr: true},
+		{name: "escaped space", in: `my\ app {path}`, want: []string{"my app", "{path}"}, unix: true},
+		{name: "escaped quote", in: `"say \"hi\""`, want: []string{`say "hi"`}, unix: true},
+		{name: "trailing backslash", in: `code \`, wantErr: true, unix: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unix && runtime.GOOS == "windows" {
+				t.Skip("backslash is a path separator on Windows")
+			}
+			got, err := splitCommandLine(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommandLine(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandLine(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandField(t *testing.T) {
+	values := map[string]string{
+		"{path}": "/tmp/a.go",
+		"{dir}":  "/tmp",
+		"{name}": "a.go",
+		"{ext}":  "go",
+		"{uri}":  "file:///tmp/a.go",
+		"{url}":  "file:///tmp/a.go",
+		"{line}": "12",
+		"{col}":  "",
+	}
+	// 路径本身包含占位符文本时不能被再次展开
+	literal := map[string]string{
+		"{path}": "/tmp/{ext}/{line}.txt",
+		"{dir}":  "/tmp/{ext}",
+		"{name}": "{line}.txt",
+		"{ext}":  "txt",
+		"{uri}":  "",
+		"{url}":  "",
+		"{line}": "3",
+		"{col}":  "",
+	}
+
+	tests := []struct {
+		name   string
+		field  string
+		values map[string]string
+		want   string
+		keep   bool
+	}{
+		{"no placeholder", "--new-window", values, "--new-window", true},
+		{"path", "{path}", values, "/tmp/a.go", true},
+		{"combined", "{dir}/{name}.{ext}", values, "/tmp/a.go.go", true},
+		{"uri", "--file-uri={uri}", values, "--file-uri=file:///tmp/a.go", true},
+		{"line only", "+{line}", values, "+12", true},
+		{"missing col", "{path}:{line}:{col}", values, "/tmp/a.go:12", true},
+		{"col only and missing", "--column={col}", values, "", false},
+		{"path with ext placeholder", "{path}", literal, "/tmp/{ext}/{line}.txt", true},
+		{"path then line", "{path}:{line}", literal, "/tmp/{ext}/{line}.txt:3", true},
+		{"dir and ext", "{dir}/x.{ext}", literal, "/tmp/{ext}/x.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep := expandField(tt.field, tt.values)
+			if keep != tt.keep || got != tt.want {
+				t.Errorf("expandField(%q) = %q, %v; want %q, %v", tt.field, got, keep, tt.want, tt.keep)
+			}
+		})
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		ctx  templateContext
+		want []string
+	}{
+		{"appends path", "code -r", templateContext{Path: "/tmp/a.go"}, []string{"code", "-r", "/tmp/a.go"}},
+		{"line", "vim +{line} {path}", templateContext{Path: "/tmp/a.go", Line: 7}, []string{"vim", "+7", "/tmp/a.go"}},
+		{"drops missing line", "vim +{line} {path}", templateContext{Path: "/tmp/a.go"}, []string{"vim", "/tmp/a.go"}},
+		{"drops option before missing line", "subl --line {line} {path}", templateContext{Path: "/tmp/a.go"}, []string{"subl", "/tmp/a.go"}},
+		{"url", "firefox {url}", templateContext{Path: "https://example.com", URL: "https://example.com"}, []string{"firefox", "https://example.com"}},
+		{"ext in path", "code {path}", templateContext{Path: "/tmp/{ext}.md"}, []string{"code", "/tmp/{ext}.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := expandCommand(tt.tmpl, tt.ctx)
+			if err != nil {
+				t.Fatalf("expandCommand(%q): %v", tt.tmpl, err)
+			}
+			if got := cmd.Argv(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandCommand(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCommandEmpty(t *testing.T) {
+	for _, tmpl := range []string{"", "+{line}", "{line}", "{col}:{line}"} {
+		if cmd, err := expandCommand(tmpl, templateContext{Path: "/tmp/a.go"}); err == nil {
+			t.Errorf("expandCommand(%q) = %q, want an error", tmpl, cmd.Argv())
+		}
+	}
+	if _, err := expandCommandTargets("{line}", []templateContext{{Path: "/tmp/a.go"}, {Path: "/tmp/b.go"}}); err == nil {
+		t.Error("expandCommandTargets(\"{line}\"): expected an error")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// isTerminal 当前平台不支持终端检测
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+// makeRaw 当前平台不支持原始模式
+func makeRaw(f *os.File) (func(), error) {
+	return nil, fmt.Errorf("⚠️ raw terminal mode is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,45 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTerminal 判断文件是否连接到控制台
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+// makeRaw 将控制台输入切换到原始模式并启用 VT 序列，返回用于恢复的函数
+func makeRaw(f *os.File) (func(), error) {
+	in := windows.Handle(f.Fd())
+	var inMode uint32
+	if err := windows.GetConsoleMode(in, &inMode); err != nil {
+		return nil, err
+	}
+
+	raw := inMode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT | windows.ENABLE_PROCESSED_OUTPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(in, raw); err != nil {
+		return nil, err
+	}
+
+	// 输出端启用 ANSI 转义序列
+	out := windows.Handle(os.Stderr.Fd())
+	var outMode uint32
+	outOK := windows.GetConsoleMode(out, &outMode) == nil
+	if outOK {
+		_ = windows.SetConsoleMode(out, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	}
+
+	return func() {
+		_ = windows.SetConsoleMode(in, inMode)
+		if outOK {
+			_ = windows.SetConsoleMode(out, outMode)
+		}
+	}, nil
+}
@@ -0,0 +1,8 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cmd
+
+// restrictUmask 当前平台没有 umask，不做任何处理
+func restrictUmask() func() {
+	return func() {}
+}
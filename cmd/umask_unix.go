@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cmd
+
+import "syscall"
+
+// restrictUmask 临时把 umask 设为 0077，新建的文件（包括 Unix socket）只有当前用户可以访问
+// 返回用于恢复原 umask 的函数
+func restrictUmask() func() {
+	previous := syscall.Umask(0077)
+	return func() { syscall.Umask(previous) }
+}
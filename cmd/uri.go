@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// schemePattern 匹配 URI 的 scheme 部分
+// 至少两个字符，避免把 Windows 盘符（C:\）误认为 scheme
+var schemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+):`)
+
+// opaqueSchemes 不带 // 的常见 scheme（例如 mailto:user@example.com）
+var opaqueSchemes = []string{"mailto", "tel", "sms", "magnet", "news", "urn", "callto", "xmpp"}
+
+// uriScheme 返回参数的 scheme（小写），不是 URI 时返回空字符串
+// 只有 scheme:// 形式、已知的无 // scheme 以及 scheme_handlers 中配置的 scheme 才视为 URI，
+// 这样 a.txt:12 这类带行号的路径不会被误认
+func uriScheme(arg string) string {
+	m := schemePattern.FindStringSubmatch(arg)
+	if m == nil {
+		return ""
+	}
+
+	scheme := strings.ToLower(m[1])
+	if strings.HasPrefix(arg[len(m[0]):], "//") || containsString(opaqueSchemes, scheme) {
+		return scheme
+	}
+	if _, exists := config.SchemeHandlers[scheme]; exists {
+		return scheme
+	}
+	return ""
+}
+
+// fileURIToPath 将 file:// URI 解码为本地路径
+func fileURIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URI: %s: %v", uri, err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("remote file URI is not supported: %s", uri)
+	}
+
+	p := u.Path
+	if p == "" {
+		p = u.Opaque
+	}
+	if runtime.GOOS == "windows" {
+		// file:///C:/Users -> C:/Users
+		if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+			p = p[1:]
+		}
+	}
+
+	// 保留 #L42 这类片段，交给 parseLocation 处理
+	if u.Fragment != "" {
+		p += "#" + u.Fragment
+	}
+	return filepath.FromSlash(p), nil
+}
+
+// openURL 打开非 file:// 的 URL
+// 优先使用 scheme_handlers 中配置的应用，否则交给平台默认的打开方式
+// 返回实际使用的应用名称
+func openURL(t templateContext) (string, error) {
+	scheme := uriScheme(t.URL)
+
+	if handler := config.SchemeHandlers[scheme]; handler != "" {
+		if debug {
+			fmt.Printf("🔍 Using scheme handler: %s -> %s\n", scheme, handler)
+		}
+		launches, err := resolveAppCommands(handler, []templateContext{t})
+		if err != nil {
+			return handler, err
+		}
+		if launches != nil {
+			for _, launch := range launches {
+				if err := launcher.Launch(launch.Command); err != nil {
+					return handler, err
+				}
+			}
+			return handler, nil
+		}
+		if debug {
+			fmt.Printf("🔍 Scheme handler not found: %s, using default opener\n", handler)
+		}
+	}
+
+	if useHeadlessFallback() {
+		return headlessLabel(), headlessOpen(t.URL)
+	}
+
+	opener, err := selectOpenerFor(canOpenPath)
+	if err != nil {
+		return "", err
+	}
+	if err := checkOpenerWait(opener); err != nil {
+		return "", err
+	}
+	return opener.Name(), launcher.Launch(opener.OpenCommand(t.URL))
+}
+
+// schemeHandlerNames 返回已配置的 scheme，按字母排序
+func schemeHandlerNames() []string {
+	names := make([]string, 0, len(config.SchemeHandlers))
+	for name := range config.SchemeHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}